@@ -2,17 +2,25 @@ package cmd
 
 import (
 	"log"
-	"os"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/audit"
+	"github.com/ravenocx/cat-socialx/internal/controllers"
+	"github.com/ravenocx/cat-socialx/internal/events"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
 	"github.com/ravenocx/cat-socialx/internal/middleware"
+	"github.com/ravenocx/cat-socialx/internal/oidc"
 	"github.com/ravenocx/cat-socialx/internal/repositories"
 	"github.com/ravenocx/cat-socialx/internal/routes"
 )
 
 func (i *Http) StartApp() {
-	serverConfig := config.FiberConfig()
+	if err := i.migrateOnStart(); err != nil {
+		log.Fatalf("Failed to migrate the database : %+v", err)
+	}
+
+	serverConfig := config.FiberConfig(i.Config.Server)
 
 	app := fiber.New(serverConfig)
 
@@ -22,18 +30,75 @@ func (i *Http) StartApp() {
 		return c.SendString("Hello, World!")
 	})
 
+	keySet, err := jwtkeys.Load(i.Config.JWT.KeysDir, i.Config.JWT.ActiveKeyID)
+	if err != nil {
+		log.Fatalf("Failed to load the JWT signing keys : %+v", err)
+	}
+
+	log.Printf("Signing JWT with key %s", keySet.ActiveKeyID())
+
 	repo := repositories.New(i.DB)
 
-	route := routes.New(&routes.V1Routes{
-		Fiber:        app,
+	stop := make(chan struct{})
+	defer close(stop)
+
+	bus := events.NewBus()
+	hub := events.NewHub(i.Config.Server.StreamHeartbeat)
+	forwarder := controllers.NewEventForwarder(i.Config.RabbitMQ, 256)
+	go forwarder.Run(stop)
+	notifications := controllers.NewNotificationRecorder(repo, 256)
+	go notifications.Run(stop)
+
+	bus.Subscribe(hub.Dispatch)
+	bus.Subscribe(forwarder.Forward)
+	bus.Subscribe(notifications.Record)
+
+	mailQueue := newMailQueue(i.Config.Mail)
+	mailQueue.Start(2)
+	defer mailQueue.Close()
+
+	baseURL := i.Config.Server.BaseURL
+
+	bus.Subscribe(controllers.EmailNotifier(repo, mailQueue, baseURL, i.Config.Mail))
+
+	oidcProviders := oidc.Load(i.Config.OIDC, baseURL)
+
+	go hub.Run(stop)
+
+	// A separate worker can run the jobs instead, see the worker command.
+	if i.Config.Server.RunJobs {
+		startJobs(repo, i.Config, stop)
+	}
+
+	controller := &controllers.V1Repository{
 		Repositories: repo,
+		Events:       bus,
+		Hub:          hub,
+		Mailer:       mailQueue,
+		BaseURL:      baseURL,
+		LoginGuard:   newLoginGuard(repo, i.Config.LoginGuard),
+		OIDC:         oidcProviders,
+		Audit:        audit.New(repo),
+		Config:       i.Config,
+		Keys:         keySet,
+	}
+
+	route := routes.New(&routes.V1Routes{
+		Fiber:      app,
+		Controller: controller,
 	})
 
 	route.UserRoutes()
 	route.CatRoutes()
 	route.CatMatchRoutes()
+	route.StreamRoutes()
+	route.NotificationRoutes()
+	route.EmailRoutes()
+	route.WellKnownRoutes()
+	route.AdminRoutes()
+	route.HealthRoutes()
 
-	if err := app.Listen(os.Getenv("SERVER_HOST") + ":" + os.Getenv("SERVER_PORT")); err != nil {
+	if err := app.Listen(i.Config.Server.Address()); err != nil {
 		log.Printf("Oops... Server is not running! Reason: %v", err)
 	}
 }
@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/ravenocx/cat-socialx/internal/db"
+	"github.com/ravenocx/cat-socialx/internal/seed"
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "start the HTTP server, the default without a command", serveCommand},
+	{"worker", "run the background jobs without the HTTP server", workerCommand},
+	{"migrate", "apply or revert the migrations: up, down [steps], status, goto <version>", migrateCommand},
+	{"seed", "fill the database with generated users, cats and matches", seedCommand},
+	{"user create-admin", "create an admin account or promote an existing one", createAdminCommand},
+	{"user reset-password", "set a new password for an account and sign it out everywhere", resetPasswordCommand},
+	{"config validate", "check the configuration and list every problem", validateConfigCommand},
+}
+
+// Execute runs the command named by args and returns the exit code. Without
+// a command the server is started, so existing deployments keep working.
+func Execute(args []string) int {
+	global := flag.NewFlagSet("cat-socialx", flag.ContinueOnError)
+	envFile := global.String("env-file", ".env", "file to load the environment from, the variables already set win")
+	configFile := global.String("config", "", "YAML configuration file, the environment wins over it (overrides CONFIG_FILE)")
+	global.Usage = func() { usage(global) }
+
+	if err := global.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if err := godotenv.Load(*envFile); err != nil && *envFile != ".env" {
+		log.Printf("Error loading %s : %+v", *envFile, err)
+		return 1
+	}
+
+	if *configFile != "" {
+		os.Setenv("CONFIG_FILE", *configFile)
+	}
+
+	args = global.Args()
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	cmd, rest, ok := findCommand(args)
+	if !ok {
+		log.Printf("Unknown command %q", strings.Join(args, " "))
+		usage(global)
+		return 2
+	}
+
+	if err := cmd.run(rest); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		log.Printf("%s failed : %+v", cmd.name, err)
+		return 1
+	}
+
+	return 0
+}
+
+func findCommand(args []string) (command, []string, bool) {
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) < len(words) {
+			continue
+		}
+
+		if strings.Join(args[:len(words)], " ") == cmd.name {
+			return cmd, args[len(words):], true
+		}
+	}
+
+	return command{}, nil, false
+}
+
+func usage(global *flag.FlagSet) {
+	out := global.Output()
+
+	fmt.Fprintf(out, "Usage: %s [--env-file file] [--config file] <command> [flags]\n\nCommands:\n", global.Name())
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-20s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(out, "\nRun a command with -h for its flags.")
+}
+
+// envFlags are flags overriding an environment variable. The parsed values
+// are written to the environment, so the app reads them the usual way.
+type envFlags struct {
+	*flag.FlagSet
+	vars map[string]string
+}
+
+func newEnvFlags(name string) *envFlags {
+	return &envFlags{
+		FlagSet: flag.NewFlagSet(name, flag.ContinueOnError),
+		vars:    map[string]string{},
+	}
+}
+
+func (f *envFlags) Env(name string, env string, usage string) {
+	f.FlagSet.String(name, "", fmt.Sprintf("%s (overrides %s)", usage, env))
+	f.vars[name] = env
+}
+
+func (f *envFlags) EnvBool(name string, env string, usage string) {
+	f.FlagSet.Bool(name, false, fmt.Sprintf("%s (overrides %s)", usage, env))
+	f.vars[name] = env
+}
+
+// Database adds the connection flags of the commands using the database.
+func (f *envFlags) Database() {
+	f.Env("db-host", "DB_HOST", "database host")
+	f.Env("db-port", "DB_PORT", "database port")
+	f.Env("db-name", "DB_NAME", "database name")
+	f.Env("db-user", "DB_USER", "database user")
+	f.Env("db-password", "DB_PASSWORD", "database password")
+	f.Env("db-ssl-mode", "DB_SSL_MODE", "database SSL mode")
+}
+
+// Parse parses the flags and applies the ones given to the environment.
+func (f *envFlags) Parse(args []string) error {
+	if err := f.FlagSet.Parse(args); err != nil {
+		return err
+	}
+
+	var err error
+	f.FlagSet.Visit(func(fl *flag.Flag) {
+		if env, ok := f.vars[fl.Name]; ok && err == nil {
+			err = os.Setenv(env, fl.Value.String())
+		}
+	})
+
+	return err
+}
+
+// withDatabase loads the configuration and connects to the database for the
+// time of fn, an invalid configuration fails before connecting.
+func withDatabase(fn func(h iHttp) error) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	// An interrupt stops the connection retries, the default handling is
+	// restored once connected.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	dbConn, err := db.CreateConnection(ctx, cfg.Database)
+	stop()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := dbConn.Close(); err != nil {
+			log.Printf("Error close database connection : %+v", err)
+		}
+	}()
+
+	return fn(New(&Http{DB: dbConn, Config: cfg}))
+}
+
+func serveCommand(args []string) error {
+	flags := newEnvFlags("serve")
+	flags.Env("host", "SERVER_HOST", "address to listen on")
+	flags.Env("port", "SERVER_PORT", "port to listen on")
+	flags.EnvBool("migrate", "DB_MIGRATE_ON_START", "apply the pending migrations before serving")
+	flags.EnvBool("jobs", "SERVE_RUN_JOBS", "run the background jobs in the server too")
+	flags.Database()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return withDatabase(func(h iHttp) error {
+		h.StartApp()
+		return nil
+	})
+}
+
+func workerCommand(args []string) error {
+	flags := newEnvFlags("worker")
+	flags.Database()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return withDatabase(func(h iHttp) error {
+		h.RunWorker()
+		return nil
+	})
+}
+
+func migrateCommand(args []string) error {
+	flags := newEnvFlags("migrate")
+	flags.Database()
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), migrateUsage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return withDatabase(func(h iHttp) error {
+		return h.Migrate(flags.Args())
+	})
+}
+
+func seedCommand(args []string) error {
+	flags := newEnvFlags("seed")
+	opts := seed.Options{}
+	flags.IntVar(&opts.Users, "users", 20, "number of users")
+	flags.IntVar(&opts.CatsPerUser, "cats-per-user", 3, "number of cats of every user")
+	flags.IntVar(&opts.MatchesPerKind, "matches", 5, "number of matches in every status")
+	flags.Int64Var(&opts.Seed, "seed", 42, "random seed, the same seed gives the same data")
+	flags.IntVar(&opts.Scale, "scale", 1, "multiplies the users and matches, for load tests")
+	flags.Database()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return withDatabase(func(h iHttp) error {
+		return h.Seed(opts)
+	})
+}
+
+func createAdminCommand(args []string) error {
+	flags := newEnvFlags("user create-admin")
+	email := flags.String("email", "", "email of the admin")
+	name := flags.String("name", "Administrator", "name of a new admin")
+	password := flags.String("password", "", "password of a new admin, generated and printed when empty")
+	flags.Database()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return fmt.Errorf("--email is required")
+	}
+
+	return withDatabase(func(h iHttp) error {
+		return h.CreateAdmin(*email, *name, *password)
+	})
+}
+
+func resetPasswordCommand(args []string) error {
+	flags := newEnvFlags("user reset-password")
+	email := flags.String("email", "", "email of the account")
+	password := flags.String("password", "", "new password, generated and printed when empty")
+	flags.Database()
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *email == "" {
+		return fmt.Errorf("--email is required")
+	}
+
+	return withDatabase(func(h iHttp) error {
+		return h.ResetPassword(*email, *password)
+	})
+}
+
+func validateConfigCommand(args []string) error {
+	flags := newEnvFlags("config validate")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	problems := validateConfig()
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Fprintln(os.Stderr, "- "+problem)
+		}
+		return fmt.Errorf("found %d configuration problems", len(problems))
+	}
+
+	fmt.Println("configuration is valid")
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+)
+
+// loadConfig loads the configuration once the flags are applied to the
+// environment, CONFIG_FILE names an optional YAML file.
+func loadConfig() (*config.Config, error) {
+	return config.Load(os.Getenv("CONFIG_FILE"))
+}
+
+// validateConfig checks the configuration without connecting to anything and
+// returns every problem found.
+func validateConfig() []string {
+	problems := []string{}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		var cfgErr *config.Error
+		if !errors.As(err, &cfgErr) {
+			return []string{err.Error()}
+		}
+		problems = append(problems, cfgErr.Problems...)
+	}
+
+	if cfg == nil {
+		return problems
+	}
+
+	if _, err := jwtkeys.Load(cfg.JWT.KeysDir, cfg.JWT.ActiveKeyID); err != nil {
+		problems = append(problems, fmt.Sprintf("JWT keys : %v", err))
+	}
+
+	return problems
+}
@@ -1,13 +1,23 @@
 package cmd
 
-import "github.com/jmoiron/sqlx"
+import (
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/seed"
+)
 
 type Http struct {
-	DB *sqlx.DB
+	DB     *sqlx.DB
+	Config *config.Config
 }
 
 type iHttp interface {
 	StartApp()
+	Migrate(args []string) error
+	RunWorker()
+	Seed(opts seed.Options) error
+	CreateAdmin(email string, name string, password string) error
+	ResetPassword(email string, password string) error
 }
 
 func New(http *Http) iHttp {
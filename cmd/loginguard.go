@@ -0,0 +1,23 @@
+package cmd
+
+import (
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/loginguard"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+)
+
+func newLoginGuard(repo *repositories.DatabaseRepositories, cfg config.LoginGuard) *loginguard.Guard {
+	var store loginguard.Store = loginguard.NewMemoryStore()
+
+	if cfg.Store == "postgres" {
+		store = repo.LoginAttemptQueries
+	}
+
+	guard := loginguard.New(store)
+
+	guard.Account.LockoutThreshold = cfg.MaxFailures
+	guard.Account.LockoutDuration = cfg.LockoutDuration
+	guard.IP.LockoutDuration = cfg.LockoutDuration
+
+	return guard
+}
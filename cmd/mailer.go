@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/mailer"
+)
+
+func newMailQueue(cfg config.Mail) *mailer.Queue {
+	var m mailer.Mailer
+
+	switch cfg.Driver {
+	case "smtp":
+		m = &mailer.SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     strconv.Itoa(cfg.SMTPPort),
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+		}
+	case "file":
+		m = &mailer.FileMailer{Dir: cfg.FileDir}
+	default:
+		m = &mailer.LogMailer{}
+	}
+
+	return mailer.NewQueue(m, cfg.From, 100)
+}
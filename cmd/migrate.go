@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/db"
+)
+
+const migrateUsage = "usage: migrate [flags] up | down [steps] | status | goto <version>"
+
+// Migrate runs the migrate subcommand.
+func (i *Http) Migrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	migrator, err := db.NewMigrator(i.DB)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migrations", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number")
+			}
+		}
+
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Reverted %d migrations", reverted)
+
+	case "goto":
+		if len(args) < 2 {
+			return fmt.Errorf(migrateUsage)
+		}
+
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("version must be a number")
+		}
+
+		changed, err := migrator.Goto(version)
+		if err != nil {
+			return err
+		}
+		log.Printf("Ran %d migrations to reach version %d", changed, version)
+
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+
+		return w.Flush()
+
+	default:
+		return fmt.Errorf(migrateUsage)
+	}
+
+	return nil
+}
+
+// migrateOnStart applies the pending migrations before serving when the
+// database is configured to.
+func (i *Http) migrateOnStart() error {
+	if !i.Config.Database.MigrateOnStart {
+		return nil
+	}
+
+	migrator, err := db.NewMigrator(i.DB)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		log.Printf("Applied %d migrations on start", applied)
+	}
+
+	return nil
+}
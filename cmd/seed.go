@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"log"
+
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/seed"
+)
+
+// Seed fills the database with generated users, cats and matches.
+func (i *Http) Seed(opts seed.Options) error {
+	summary, err := seed.Run(repositories.New(i.DB), i.Config.Password, opts)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Seeded %d users and %d cats, matches by status : %v", summary.Users, summary.Cats, summary.Matches)
+	log.Printf("Every seeded user signs in with the password %q", seed.Password)
+
+	return nil
+}
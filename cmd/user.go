@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/audit"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// CreateAdmin creates a verified admin account, or promotes the account that
+// already uses the email.
+func (i *Http) CreateAdmin(email string, name string, password string) error {
+	repo := repositories.New(i.DB)
+	auditLog := audit.New(repo)
+
+	user, err := repo.GetUserByEmail(email)
+	if err == nil {
+		if user.UserRole == models.UserRoleAdmin {
+			log.Printf("%s is already an admin", email)
+			return nil
+		}
+
+		if err := repo.UpdateUserRole(user.ID, models.UserRoleAdmin); err != nil {
+			return err
+		}
+
+		auditLog.Record(audit.Entry{
+			Action:     models.AuditAdminGranted,
+			TargetType: models.AuditTargetUser,
+			TargetID:   user.ID.String(),
+			Metadata:   map[string]interface{}{"via": "cli"},
+		})
+
+		log.Printf("Promoted %s to admin", email)
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	generated := password == ""
+	if generated {
+		if password, err = utils.GenerateRandomPassword(); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.ValidatePassword(i.Config.Password, password, email, name); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user = models.User{
+		ID:              uuid.New(),
+		Email:           email,
+		Name:            name,
+		Password:        password,
+		UserStatus:      1,
+		UserRole:        models.UserRoleAdmin,
+		CreatedAt:       now,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := utils.NewValidator().Struct(user); err != nil {
+		return fmt.Errorf("%v", utils.ValidatorErrors(err))
+	}
+
+	if user.Password, err = utils.GeneratePassword(i.Config.Password, password); err != nil {
+		return err
+	}
+
+	if err := repo.CreateUser(&user); err != nil {
+		return err
+	}
+
+	auditLog.Record(audit.Entry{
+		Action:     models.AuditAdminGranted,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+		Metadata:   map[string]interface{}{"via": "cli", "created": true},
+	})
+
+	log.Printf("Created the admin %s", email)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password and revokes the sessions and tokens of
+// the account.
+func (i *Http) ResetPassword(email string, password string) error {
+	repo := repositories.New(i.DB)
+
+	user, err := repo.GetUserByEmail(email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no account uses %s", email)
+		}
+		return err
+	}
+
+	generated := password == ""
+	if generated {
+		if password, err = utils.GenerateRandomPassword(); err != nil {
+			return err
+		}
+	}
+
+	if err := utils.ValidatePassword(i.Config.Password, password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	passwordHash, err := utils.GeneratePassword(i.Config.Password, password)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.UpdateUserPassword(user.ID, passwordHash); err != nil {
+		return err
+	}
+
+	audit.New(repo).Record(audit.Entry{
+		Action:     models.AuditPasswordReset,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID.String(),
+		Metadata:   map[string]interface{}{"via": "cli"},
+	})
+
+	log.Printf("Reset the password of %s", email)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+
+	return nil
+}
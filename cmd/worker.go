@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jobs"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+)
+
+// startJobs runs the background jobs until stop is closed.
+func startJobs(repo *repositories.DatabaseRepositories, cfg *config.Config, stop <-chan struct{}) {
+	retention := &jobs.NotificationRetention{
+		Repositories: repo,
+		Retention:    cfg.Notifications.Retention,
+		Interval:     time.Hour,
+	}
+
+	purge := &jobs.AccountPurge{
+		Repositories: repo,
+		Interval:     time.Hour,
+	}
+
+	go retention.Run(stop)
+	go purge.Run(stop)
+}
+
+// RunWorker runs the background jobs until the process is interrupted, for
+// deployments that keep them out of the server instances.
+func (i *Http) RunWorker() {
+	repo := repositories.New(i.DB)
+
+	stop := make(chan struct{})
+	startJobs(repo, i.Config, stop)
+
+	log.Println("Worker started")
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	close(stop)
+
+	log.Println("Worker stopped")
+}
@@ -0,0 +1,620 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is every setting of the app, loaded once on start and handed to the
+// components that need it.
+type Config struct {
+	Server            Server            `yaml:"server"`
+	Database          Database          `yaml:"database"`
+	RabbitMQ          RabbitMQ          `yaml:"rabbitmq"`
+	JWT               JWT               `yaml:"jwt"`
+	Mail              Mail              `yaml:"mail"`
+	LoginGuard        LoginGuard        `yaml:"loginGuard"`
+	Password          Password          `yaml:"password"`
+	PasswordReset     PasswordReset     `yaml:"passwordReset"`
+	EmailVerification EmailVerification `yaml:"emailVerification"`
+	MFA               MFA               `yaml:"mfa"`
+	OIDC              OIDC              `yaml:"oidc"`
+	APIKeys           APIKeys           `yaml:"apiKeys"`
+	Accounts          Accounts          `yaml:"accounts"`
+	Notifications     Notifications     `yaml:"notifications"`
+}
+
+type Server struct {
+	// Environment is development or production, the sample secrets are only
+	// accepted in development.
+	Environment string        `yaml:"environment"`
+	Host        string        `yaml:"host"`
+	Port        int           `yaml:"port"`
+	ReadTimeout time.Duration `yaml:"readTimeout"`
+	// BaseURL is the public address used in emails and OIDC redirects.
+	BaseURL         string        `yaml:"baseUrl"`
+	StreamHeartbeat time.Duration `yaml:"streamHeartbeat"`
+	// RunJobs runs the background jobs in the server, turn it off when a
+	// worker process runs them.
+	RunJobs bool `yaml:"runJobs"`
+}
+
+func (s Server) Address() string {
+	return s.Host + ":" + strconv.Itoa(s.Port)
+}
+
+type Database struct {
+	Host               string `yaml:"host"`
+	Port               int    `yaml:"port"`
+	User               string `yaml:"user"`
+	Password           string `yaml:"password"`
+	Name               string `yaml:"name"`
+	SSLMode            string `yaml:"sslMode"`
+	MaxConnections     int    `yaml:"maxConnections"`
+	MaxIdleConnections int    `yaml:"maxIdleConnections"`
+	// ConnMaxLifetime closes connections after this time, so they move to a
+	// restarted or failed over server. Zero keeps them forever.
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	// ConnMaxIdleTime closes connections unused for this time.
+	ConnMaxIdleTime time.Duration `yaml:"connMaxIdleTime"`
+	// ConnectAttempts and ConnectBackoff retry the first connection, the
+	// backoff doubles after every failed attempt.
+	ConnectAttempts int           `yaml:"connectAttempts"`
+	ConnectBackoff  time.Duration `yaml:"connectBackoff"`
+	MigrateOnStart  bool          `yaml:"migrateOnStart"`
+}
+
+func (d Database) DSN() string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode,
+	)
+}
+
+type RabbitMQ struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+func (r RabbitMQ) URL() string {
+	u := url.URL{
+		Scheme: "amqp",
+		User:   url.UserPassword(r.Username, r.Password),
+		Host:   r.Host + ":" + strconv.Itoa(r.Port),
+		Path:   "/",
+	}
+
+	return u.String()
+}
+
+type JWT struct {
+	// KeysDir holds the PEM signing keys, without it an ephemeral key signs.
+	KeysDir     string        `yaml:"keysDir"`
+	ActiveKeyID string        `yaml:"activeKeyId"`
+	Issuer      string        `yaml:"issuer"`
+	Audience    string        `yaml:"audience"`
+	AccessTTL   time.Duration `yaml:"accessTtl"`
+	RefreshTTL  time.Duration `yaml:"refreshTtl"`
+	// ClockSkew is tolerated on exp, nbf and iat for the clocks of other
+	// services being slightly off.
+	ClockSkew time.Duration `yaml:"clockSkew"`
+}
+
+type Mail struct {
+	Driver       string `yaml:"driver"`
+	From         string `yaml:"from"`
+	SMTPHost     string `yaml:"smtpHost"`
+	SMTPPort     int    `yaml:"smtpPort"`
+	SMTPUsername string `yaml:"smtpUsername"`
+	SMTPPassword string `yaml:"smtpPassword"`
+	FileDir      string `yaml:"fileDir"`
+	// UnsubscribeSecret signs the unsubscribe links of the emails.
+	UnsubscribeSecret string `yaml:"unsubscribeSecret"`
+}
+
+type LoginGuard struct {
+	Store           string        `yaml:"store"`
+	MaxFailures     int           `yaml:"maxFailures"`
+	LockoutDuration time.Duration `yaml:"lockoutDuration"`
+}
+
+// Password is the hashing and the policy of the passwords. The argon2id
+// parameters are stored in every hash, changing them rehashes on sign in.
+type Password struct {
+	Hasher            string  `yaml:"hasher"`
+	BcryptCost        int     `yaml:"bcryptCost"`
+	Argon2MemoryKiB   int     `yaml:"argon2MemoryKib"`
+	Argon2Iterations  int     `yaml:"argon2Iterations"`
+	Argon2Parallelism int     `yaml:"argon2Parallelism"`
+	MinLength         int     `yaml:"minLength"`
+	MaxLength         int     `yaml:"maxLength"`
+	MinEntropyBits    float64 `yaml:"minEntropyBits"`
+}
+
+type PasswordReset struct {
+	MaxPerHour int           `yaml:"maxPerHour"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+type EmailVerification struct {
+	// Secret signs the verification links.
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+type MFA struct {
+	Issuer string `yaml:"issuer"`
+	// SecretKey encrypts the stored TOTP secrets and signs the challenge
+	// tokens of the second step.
+	SecretKey string `yaml:"secretKey"`
+}
+
+type OIDC struct {
+	Providers []OIDCProvider `yaml:"providers"`
+}
+
+// OIDCProvider without a redirect URL uses the callback route under the base
+// URL, without scopes it asks for openid, email and profile.
+type OIDCProvider struct {
+	Name         string   `yaml:"name"`
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"clientId"`
+	ClientSecret string   `yaml:"clientSecret"`
+	RedirectURL  string   `yaml:"redirectUrl"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+type APIKeys struct {
+	MaxPerUser int `yaml:"maxPerUser"`
+}
+
+type Accounts struct {
+	// DeletionGrace is the time a deleted account stays anonymized before
+	// it is purged.
+	DeletionGrace time.Duration `yaml:"deletionGrace"`
+}
+
+type Notifications struct {
+	Retention time.Duration `yaml:"retention"`
+}
+
+// Default is the configuration without any file or variable set.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Environment:     EnvironmentProduction,
+			Port:            5000,
+			ReadTimeout:     time.Minute,
+			StreamHeartbeat: time.Second * 25,
+			RunJobs:         true,
+		},
+		Database: Database{
+			Host:               "127.0.0.1",
+			Port:               5432,
+			User:               "postgres",
+			Name:               "postgres",
+			SSLMode:            "disable",
+			MaxConnections:     20,
+			MaxIdleConnections: 10,
+			ConnMaxLifetime:    time.Minute * 30,
+			ConnMaxIdleTime:    time.Minute * 5,
+			ConnectAttempts:    6,
+			ConnectBackoff:     time.Second,
+		},
+		RabbitMQ: RabbitMQ{
+			Host:     "127.0.0.1",
+			Port:     5672,
+			Username: "guest",
+			Password: "guest",
+		},
+		JWT: JWT{
+			Issuer:     "cat-socialx",
+			Audience:   "cat-socialx-api",
+			AccessTTL:  time.Minute * 15,
+			RefreshTTL: time.Hour * 720,
+			ClockSkew:  time.Second * 30,
+		},
+		Mail: Mail{
+			Driver:   "log",
+			From:     "Cat Social <no-reply@cat-social.local>",
+			SMTPPort: 25,
+		},
+		LoginGuard: LoginGuard{
+			Store:           "memory",
+			MaxFailures:     10,
+			LockoutDuration: time.Minute * 15,
+		},
+		Password: Password{
+			Hasher:            "argon2id",
+			BcryptCost:        10,
+			Argon2MemoryKiB:   64 * 1024,
+			Argon2Iterations:  3,
+			Argon2Parallelism: 2,
+			MinLength:         8,
+			MaxLength:         128,
+			MinEntropyBits:    40,
+		},
+		PasswordReset:     PasswordReset{MaxPerHour: 3, TTL: time.Minute * 30},
+		EmailVerification: EmailVerification{TTL: time.Hour * 24},
+		MFA:               MFA{Issuer: "Cat Social"},
+		APIKeys:           APIKeys{MaxPerUser: 10},
+		Accounts:          Accounts{DeletionGrace: day * 30},
+		Notifications:     Notifications{Retention: day * 90},
+	}
+}
+
+// Load applies the YAML file, when file isn't empty, and then the environment
+// over the defaults. The .env file is loaded into the environment before, so
+// a variable wins over the file. Every problem found is returned at once.
+func Load(file string) (*Config, error) {
+	cfg := Default()
+	problems := []string{}
+
+	if file != "" {
+		if err := cfg.loadFile(file); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	for _, b := range cfg.envBindings() {
+		value := strings.TrimSpace(os.Getenv(b.env))
+		if value == "" {
+			continue
+		}
+
+		if err := b.set(value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s, got %q", b.env, err.Error(), value))
+		}
+	}
+
+	if names := strings.TrimSpace(os.Getenv("OIDC_PROVIDERS")); names != "" {
+		cfg.OIDC.Providers = oidcProvidersFromEnv(names)
+	}
+
+	problems = append(problems, cfg.Validate()...)
+
+	if len(problems) > 0 {
+		return nil, &Error{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(file string) error {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading %s, %w", file, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("error parsing %s, %w", file, err)
+	}
+
+	return nil
+}
+
+// Error lists every problem of the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// The environments of Server.Environment.
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentProduction  = "production"
+)
+
+// SampleSecretMarker is part of the secrets of the sample files, they are
+// public and refused outside development.
+const SampleSecretMarker = "change-me"
+
+// MinSecretLength is the minimum length of the signing and encryption
+// secrets, an empty or short secret makes the tokens forgeable.
+const MinSecretLength = 32
+
+// Validate returns the problems of the configuration, empty when it is valid.
+func (c *Config) Validate() []string {
+	problems := []string{}
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	validPort := func(port int) bool { return port > 0 && port <= 65535 }
+
+	// Secrets are never echoed back, only their length is checked.
+	checkSecret := func(secret string, name string) {
+		check(len(secret) >= MinSecretLength, "%s is required and must be at least %d characters", name, MinSecretLength)
+		check(c.Server.Environment == EnvironmentDevelopment || !strings.Contains(strings.ToLower(secret), SampleSecretMarker), "%s is a sample value, only accepted in development", name)
+	}
+
+	check(c.Server.Environment == EnvironmentDevelopment || c.Server.Environment == EnvironmentProduction, "environment must be development or production, got %q", c.Server.Environment)
+
+	check(validPort(c.Server.Port), "server port must be between 1 and 65535")
+	check(c.Server.ReadTimeout >= 0, "server read timeout can't be negative")
+	check(c.Server.StreamHeartbeat > 0, "stream heartbeat must be positive")
+
+	if c.Server.BaseURL == "" {
+		problems = append(problems, "base URL is required (APP_BASE_URL)")
+	} else if u, err := url.Parse(c.Server.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("base URL must be an absolute URL, got %q", c.Server.BaseURL))
+	}
+
+	check(c.Database.Host != "", "database host is required")
+	check(validPort(c.Database.Port), "database port must be between 1 and 65535")
+	check(c.Database.User != "", "database user is required")
+	check(c.Database.Name != "", "database name is required")
+	check(c.Database.MaxConnections >= 0, "database max connections can't be negative")
+	check(c.Database.MaxIdleConnections >= 0, "database max idle connections can't be negative")
+	check(c.Database.MaxConnections == 0 || c.Database.MaxIdleConnections <= c.Database.MaxConnections, "database max idle connections can't be more than the max connections")
+	check(c.Database.ConnMaxLifetime >= 0, "database connection max lifetime can't be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database connection max idle time can't be negative")
+	check(c.Database.ConnectAttempts > 0, "database connect attempts must be at least 1")
+	check(c.Database.ConnectBackoff > 0, "database connect backoff must be positive")
+
+	check(c.RabbitMQ.Host != "", "rabbitmq host is required")
+	check(validPort(c.RabbitMQ.Port), "rabbitmq port must be between 1 and 65535")
+
+	check(c.JWT.Issuer != "", "jwt issuer is required")
+	check(c.JWT.Audience != "", "jwt audience is required")
+	check(c.JWT.AccessTTL > 0, "jwt access token TTL must be positive")
+	check(c.JWT.RefreshTTL > 0, "jwt refresh token TTL must be positive")
+	check(c.JWT.ClockSkew >= 0, "jwt clock skew can't be negative")
+
+	if c.JWT.KeysDir != "" {
+		info, err := os.Stat(c.JWT.KeysDir)
+		check(err == nil && info.IsDir(), "jwt keys directory %q doesn't exist", c.JWT.KeysDir)
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		check(c.Mail.SMTPHost != "", "mail SMTP host is required with the smtp driver")
+		check(validPort(c.Mail.SMTPPort), "mail SMTP port must be between 1 and 65535")
+	case "file":
+		check(c.Mail.FileDir != "", "mail file directory is required with the file driver")
+	default:
+		problems = append(problems, fmt.Sprintf("mail driver must be log, smtp or file, got %q", c.Mail.Driver))
+	}
+	check(c.Mail.From != "", "mail from address is required")
+
+	check(c.LoginGuard.Store == "memory" || c.LoginGuard.Store == "postgres", "login guard store must be memory or postgres, got %q", c.LoginGuard.Store)
+	check(c.LoginGuard.MaxFailures > 0, "login guard max failures must be positive")
+	check(c.LoginGuard.LockoutDuration > 0, "login guard lockout duration must be positive")
+
+	switch c.Password.Hasher {
+	case "argon2id":
+		check(c.Password.Argon2Iterations > 0, "argon2 iterations must be positive")
+		check(c.Password.Argon2Parallelism > 0 && c.Password.Argon2Parallelism <= 255, "argon2 parallelism must be between 1 and 255")
+		check(c.Password.Argon2MemoryKiB >= 8*c.Password.Argon2Parallelism, "argon2 memory must be at least 8 KiB per thread")
+	case "bcrypt":
+		check(c.Password.BcryptCost >= 4 && c.Password.BcryptCost <= 31, "bcrypt cost must be between 4 and 31")
+	default:
+		problems = append(problems, fmt.Sprintf("password hasher must be argon2id or bcrypt, got %q", c.Password.Hasher))
+	}
+	check(c.Password.MinLength > 0, "password min length must be positive")
+	check(c.Password.MaxLength >= c.Password.MinLength, "password max length can't be less than the min length")
+	check(c.Password.MinEntropyBits >= 0, "password min entropy can't be negative")
+
+	check(c.PasswordReset.MaxPerHour > 0, "password reset max per hour must be positive")
+	check(c.PasswordReset.TTL > 0, "password reset TTL must be positive")
+	check(c.EmailVerification.TTL > 0, "email verification TTL must be positive")
+	checkSecret(c.EmailVerification.Secret, "email verification secret")
+	checkSecret(c.Mail.UnsubscribeSecret, "mail unsubscribe secret")
+	check(c.MFA.Issuer != "", "mfa issuer is required")
+	checkSecret(c.MFA.SecretKey, "mfa secret key")
+
+	names := map[string]bool{}
+	for _, p := range c.OIDC.Providers {
+		check(p.Name != "", "oidc providers need a name")
+		check(!names[p.Name], "oidc provider %s is listed twice", p.Name)
+		check(p.Issuer != "" && p.ClientID != "", "oidc provider %s needs an issuer and a client ID", p.Name)
+		if p.RedirectURL != "" {
+			u, err := url.Parse(p.RedirectURL)
+			check(err == nil && u.Scheme != "" && u.Host != "", "oidc provider %s redirect URL must be an absolute URL", p.Name)
+		}
+		names[p.Name] = true
+	}
+
+	check(c.APIKeys.MaxPerUser > 0, "api keys max per user must be positive")
+	check(c.Accounts.DeletionGrace >= 0, "account deletion grace period can't be negative")
+	check(c.Notifications.Retention > 0, "notification retention must be positive")
+
+	return problems
+}
+
+type envBinding struct {
+	env string
+	set func(value string) error
+}
+
+func stringVar(env string, field *string) envBinding {
+	return envBinding{env, func(value string) error {
+		*field = value
+		return nil
+	}}
+}
+
+func floatVar(env string, field *float64) envBinding {
+	return envBinding{env, func(value string) error {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		*field = v
+		return nil
+	}}
+}
+
+func intVar(env string, field *int) envBinding {
+	return envBinding{env, func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		*field = v
+		return nil
+	}}
+}
+
+func boolVar(env string, field *bool) envBinding {
+	return envBinding{env, func(value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+		*field = v
+		return nil
+	}}
+}
+
+// durationVar reads a duration like 90s or 15m. A bare number keeps the unit
+// the variable always had, so existing .env files keep working.
+func durationVar(env string, field *time.Duration, unit time.Duration) envBinding {
+	return envBinding{env, func(value string) error {
+		if n, err := strconv.Atoi(value); err == nil {
+			*field = time.Duration(n) * unit
+			return nil
+		}
+
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration like 30s or 15m, or a number of %s", unitName(unit))
+		}
+		*field = v
+		return nil
+	}}
+}
+
+func unitName(unit time.Duration) string {
+	switch unit {
+	case time.Second:
+		return "seconds"
+	case time.Minute:
+		return "minutes"
+	case time.Hour:
+		return "hours"
+	default:
+		return "days"
+	}
+}
+
+const day = time.Hour * 24
+
+func (c *Config) envBindings() []envBinding {
+	return []envBinding{
+		stringVar("APP_ENV", &c.Server.Environment),
+		stringVar("SERVER_HOST", &c.Server.Host),
+		intVar("SERVER_PORT", &c.Server.Port),
+		durationVar("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout, time.Second),
+		stringVar("APP_BASE_URL", &c.Server.BaseURL),
+		durationVar("STREAM_HEARTBEAT_SECONDS", &c.Server.StreamHeartbeat, time.Second),
+		boolVar("SERVE_RUN_JOBS", &c.Server.RunJobs),
+
+		stringVar("DB_HOST", &c.Database.Host),
+		intVar("DB_PORT", &c.Database.Port),
+		stringVar("DB_USER", &c.Database.User),
+		stringVar("DB_PASSWORD", &c.Database.Password),
+		stringVar("DB_NAME", &c.Database.Name),
+		stringVar("DB_SSL_MODE", &c.Database.SSLMode),
+		intVar("DB_MAX_CONNECTIONS", &c.Database.MaxConnections),
+		intVar("DB_MAX_IDLE_CONNECTIONS", &c.Database.MaxIdleConnections),
+		durationVar("DB_MAX_LIFETIME_CONNECTIONS", &c.Database.ConnMaxLifetime, time.Minute),
+		durationVar("DB_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime, time.Minute),
+		intVar("DB_CONNECT_ATTEMPTS", &c.Database.ConnectAttempts),
+		durationVar("DB_CONNECT_BACKOFF", &c.Database.ConnectBackoff, time.Second),
+		boolVar("DB_MIGRATE_ON_START", &c.Database.MigrateOnStart),
+
+		stringVar("RABBITMQ_HOST", &c.RabbitMQ.Host),
+		intVar("RABBITMQ_PORT", &c.RabbitMQ.Port),
+		stringVar("RABBITMQ_USERNAME", &c.RabbitMQ.Username),
+		stringVar("RABBITMQ_PASSWORD", &c.RabbitMQ.Password),
+
+		stringVar("JWT_KEYS_DIR", &c.JWT.KeysDir),
+		stringVar("JWT_ACTIVE_KID", &c.JWT.ActiveKeyID),
+		stringVar("JWT_ISSUER", &c.JWT.Issuer),
+		stringVar("JWT_AUDIENCE", &c.JWT.Audience),
+		durationVar("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT", &c.JWT.AccessTTL, time.Minute),
+		durationVar("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT", &c.JWT.RefreshTTL, time.Hour),
+		durationVar("JWT_CLOCK_SKEW_SECONDS", &c.JWT.ClockSkew, time.Second),
+
+		stringVar("MAIL_DRIVER", &c.Mail.Driver),
+		stringVar("MAIL_FROM", &c.Mail.From),
+		stringVar("MAIL_SMTP_HOST", &c.Mail.SMTPHost),
+		intVar("MAIL_SMTP_PORT", &c.Mail.SMTPPort),
+		stringVar("MAIL_SMTP_USERNAME", &c.Mail.SMTPUsername),
+		stringVar("MAIL_SMTP_PASSWORD", &c.Mail.SMTPPassword),
+		stringVar("MAIL_FILE_DIR", &c.Mail.FileDir),
+		stringVar("MAIL_UNSUBSCRIBE_SECRET", &c.Mail.UnsubscribeSecret),
+
+		stringVar("LOGIN_GUARD_STORE", &c.LoginGuard.Store),
+		intVar("LOGIN_MAX_FAILURES", &c.LoginGuard.MaxFailures),
+		durationVar("LOGIN_LOCKOUT_MINUTES", &c.LoginGuard.LockoutDuration, time.Minute),
+
+		stringVar("PASSWORD_HASHER", &c.Password.Hasher),
+		intVar("BCRYPT_SALT", &c.Password.BcryptCost),
+		intVar("ARGON2_MEMORY_KIB", &c.Password.Argon2MemoryKiB),
+		intVar("ARGON2_ITERATIONS", &c.Password.Argon2Iterations),
+		intVar("ARGON2_PARALLELISM", &c.Password.Argon2Parallelism),
+		intVar("PASSWORD_MIN_LENGTH", &c.Password.MinLength),
+		intVar("PASSWORD_MAX_LENGTH", &c.Password.MaxLength),
+		floatVar("PASSWORD_MIN_ENTROPY_BITS", &c.Password.MinEntropyBits),
+
+		intVar("PASSWORD_RESET_MAX_PER_HOUR", &c.PasswordReset.MaxPerHour),
+		durationVar("PASSWORD_RESET_TTL_MINUTES", &c.PasswordReset.TTL, time.Minute),
+		stringVar("EMAIL_VERIFICATION_SECRET", &c.EmailVerification.Secret),
+		durationVar("EMAIL_VERIFICATION_TTL_HOURS", &c.EmailVerification.TTL, time.Hour),
+		stringVar("MFA_ISSUER", &c.MFA.Issuer),
+		stringVar("MFA_SECRET_KEY", &c.MFA.SecretKey),
+		intVar("API_KEYS_MAX_PER_USER", &c.APIKeys.MaxPerUser),
+		durationVar("ACCOUNT_DELETION_GRACE_DAYS", &c.Accounts.DeletionGrace, day),
+		durationVar("NOTIFICATION_RETENTION_DAYS", &c.Notifications.Retention, day),
+	}
+}
+
+// oidcProvidersFromEnv reads the providers listed in OIDC_PROVIDERS, each
+// configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, and
+// optionally _REDIRECT_URL and _SCOPES.
+func oidcProvidersFromEnv(names string) []OIDCProvider {
+	providers := []OIDCProvider{}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		})
+	}
+
+	return providers
+}
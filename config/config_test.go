@@ -0,0 +1,154 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// validConfig is the default configuration with the settings it leaves empty.
+func validConfig() *Config {
+	c := Default()
+	c.Server.BaseURL = "https://cats.example.com"
+	c.EmailVerification.Secret = testSecret
+	c.Mail.UnsubscribeSecret = testSecret
+	c.MFA.SecretKey = testSecret
+
+	return c
+}
+
+func TestValidateDefaults(t *testing.T) {
+	if problems := validConfig().Validate(); len(problems) != 0 {
+		t.Errorf("Validate = %v, want no problems", problems)
+	}
+
+	// Without the secrets and the base URL, each one is reported.
+	if problems := Default().Validate(); len(problems) != 4 {
+		t.Errorf("Validate of the defaults = %v, want the base URL and the three secrets", problems)
+	}
+}
+
+func TestValidateProblems(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    func(c *Config)
+		problem string
+	}{
+		{"short secret", func(c *Config) { c.MFA.SecretKey = "short" }, "mfa secret key is required"},
+		{"sample secret in production", func(c *Config) {
+			c.Mail.UnsubscribeSecret = "dev-unsubscribe-secret-change-me-0000"
+		}, "mail unsubscribe secret is a sample value"},
+		{"sample secret in upper case", func(c *Config) {
+			c.EmailVerification.Secret = "DEV-VERIFICATION-SECRET-CHANGE-ME-00"
+		}, "email verification secret is a sample value"},
+		{"unknown environment", func(c *Config) { c.Server.Environment = "staging" }, "environment must be development or production"},
+		{"server port", func(c *Config) { c.Server.Port = 70000 }, "server port"},
+		{"relative base URL", func(c *Config) { c.Server.BaseURL = "/cats" }, "base URL must be an absolute URL"},
+		{"idle connections over the max", func(c *Config) { c.Database.MaxIdleConnections = 50 }, "max idle connections"},
+		{"no connect attempt", func(c *Config) { c.Database.ConnectAttempts = 0 }, "connect attempts"},
+		{"no access TTL", func(c *Config) { c.JWT.AccessTTL = 0 }, "access token TTL"},
+		{"missing keys directory", func(c *Config) { c.JWT.KeysDir = "/does/not/exist" }, "jwt keys directory"},
+		{"mail driver", func(c *Config) { c.Mail.Driver = "pigeon" }, "mail driver must be log, smtp or file"},
+		{"smtp without host", func(c *Config) { c.Mail.Driver = "smtp"; c.Mail.SMTPHost = "" }, "SMTP host"},
+		{"login guard store", func(c *Config) { c.LoginGuard.Store = "redis" }, "login guard store"},
+		{"password hasher", func(c *Config) { c.Password.Hasher = "md5" }, "password hasher"},
+		{"bcrypt cost", func(c *Config) { c.Password.Hasher = "bcrypt"; c.Password.BcryptCost = 40 }, "bcrypt cost"},
+		{"argon2 memory", func(c *Config) { c.Password.Argon2MemoryKiB = 4 }, "argon2 memory"},
+		{"password lengths", func(c *Config) { c.Password.MaxLength = 4 }, "max length can't be less"},
+		{"duplicate oidc provider", func(c *Config) {
+			c.OIDC.Providers = []OIDCProvider{
+				{Name: "google", Issuer: "https://accounts.google.com", ClientID: "a"},
+				{Name: "google", Issuer: "https://accounts.google.com", ClientID: "b"},
+			}
+		}, "oidc provider google is listed twice"},
+		{"oidc provider without client", func(c *Config) {
+			c.OIDC.Providers = []OIDCProvider{{Name: "google", Issuer: "https://accounts.google.com"}}
+		}, "needs an issuer and a client ID"},
+	}
+
+	for _, tt := range tests {
+		c := validConfig()
+		tt.edit(c)
+
+		problems := c.Validate()
+		if len(problems) != 1 || !strings.Contains(problems[0], tt.problem) {
+			t.Errorf("%s : Validate = %v, want one problem about %q", tt.name, problems, tt.problem)
+		}
+	}
+}
+
+func TestValidateAcceptsSampleSecretsInDevelopment(t *testing.T) {
+	c := validConfig()
+	c.Server.Environment = EnvironmentDevelopment
+	c.MFA.SecretKey = "dev-mfa-secret-key-change-me-0000"
+
+	if problems := c.Validate(); len(problems) != 0 {
+		t.Errorf("Validate = %v, want no problems", problems)
+	}
+}
+
+func TestValidateNeverEchoesSecrets(t *testing.T) {
+	c := validConfig()
+	c.MFA.SecretKey = "leaked-change-me-but-long-enough-000"
+
+	for _, problem := range c.Validate() {
+		if strings.Contains(problem, c.MFA.SecretKey) {
+			t.Errorf("problem %q contains the secret", problem)
+		}
+	}
+}
+
+func TestLoadFromEnvironment(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+	t.Setenv("APP_BASE_URL", "http://127.0.0.1:5000")
+	t.Setenv("EMAIL_VERIFICATION_SECRET", testSecret)
+	t.Setenv("MAIL_UNSUBSCRIBE_SECRET", testSecret)
+	t.Setenv("MFA_SECRET_KEY", testSecret)
+	t.Setenv("SERVER_PORT", "8000")
+	t.Setenv("SERVER_READ_TIMEOUT", "90")
+	t.Setenv("DB_MAX_IDLE_TIME", "2m")
+	t.Setenv("OIDC_PROVIDERS", "mock")
+	t.Setenv("OIDC_MOCK_ISSUER", "http://localhost:8081/default")
+	t.Setenv("OIDC_MOCK_CLIENT_ID", "cat-socialx")
+
+	c, err := Load("")
+	if err != nil {
+		t.Fatalf("Load : %v", err)
+	}
+
+	if c.Server.Environment != EnvironmentDevelopment || c.Server.Port != 8000 {
+		t.Errorf("server = %+v, want the environment and port of the variables", c.Server)
+	}
+	if c.Server.ReadTimeout != 90*time.Second || c.Database.ConnMaxIdleTime != 2*time.Minute {
+		t.Errorf("durations = %s and %s, want 90s and 2m", c.Server.ReadTimeout, c.Database.ConnMaxIdleTime)
+	}
+	if len(c.OIDC.Providers) != 1 || c.OIDC.Providers[0].Name != "mock" || c.OIDC.Providers[0].ClientID != "cat-socialx" {
+		t.Errorf("oidc providers = %+v, want the mock provider", c.OIDC.Providers)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("MFA_SECRET_KEY", "")
+	t.Setenv("SERVER_PORT", "five thousand")
+	t.Setenv("DB_CONNECT_BACKOFF", "soon")
+
+	_, err := Load("")
+
+	var cfgErr *Error
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("Load error = %v, want a *config.Error", err)
+	}
+
+	for _, want := range []string{"SERVER_PORT", "DB_CONNECT_BACKOFF", "mfa secret key is required"} {
+		found := false
+		for _, problem := range cfgErr.Problems {
+			found = found || strings.Contains(problem, want)
+		}
+		if !found {
+			t.Errorf("problems = %v, want one about %s", cfgErr.Problems, want)
+		}
+	}
+}
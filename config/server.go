@@ -1,19 +1,12 @@
 package config
 
 import (
-	"os"
-	"strconv"
-	"time"
-
 	"github.com/gofiber/fiber/v2"
 )
 
-func FiberConfig() fiber.Config {
-	// Define server settings.
-	readTimeoutSecondsCount, _ := strconv.Atoi(os.Getenv("SERVER_READ_TIMEOUT"))
-
+func FiberConfig(cfg Server) fiber.Config {
 	// Return Fiber configuration.
 	return fiber.Config{
-		ReadTimeout: time.Second * time.Duration(readTimeoutSecondsCount),
+		ReadTimeout: cfg.ReadTimeout,
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,69 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// Store keeps the audit trail, implemented by the repositories.
+type Store interface {
+	CreateAuditEvent(e *models.AuditEvent) error
+}
+
+// Service writes audit events. A failing write is logged and never fails the
+// action being audited.
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Entry is what the caller knows about the action, the service adds the ID
+// and the time.
+type Entry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	IP         string
+	UserAgent  string
+	Metadata   map[string]interface{}
+}
+
+func (s *Service) Record(entry Entry) {
+	if s == nil {
+		return
+	}
+
+	metadata := json.RawMessage("{}")
+	if len(entry.Metadata) > 0 {
+		b, err := json.Marshal(entry.Metadata)
+		if err != nil {
+			log.Printf("Failed to marshal audit metadata : %+v", err)
+		} else {
+			metadata = b
+		}
+	}
+
+	event := &models.AuditEvent{
+		ID:         uuid.New(),
+		ActorID:    entry.ActorID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		Metadata:   metadata,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.store.CreateAuditEvent(event); err != nil {
+		log.Printf("Failed to record the audit event %s : %+v", entry.Action, err)
+	}
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// ExportUserData sends a ZIP with one JSON file per kind of personal data.
+func (i *V1Repository) ExportUserData(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil || user.DeletedAt != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "user not found",
+		})
+	}
+
+	cats, err := i.Repositories.GetExportCats(user.ID)
+	if err != nil {
+		log.Printf("Failed to get the cats to export : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	matches, err := i.Repositories.GetExportMatches(user.ID)
+	if err != nil {
+		log.Printf("Failed to get the matches to export : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	messages, err := i.Repositories.GetExportMessages(user.ID)
+	if err != nil {
+		log.Printf("Failed to get the messages to export : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	archive, err := zipJSONFiles([]jsonFile{
+		{"profile.json", user.Profile()},
+		{"cats.json", cats},
+		{"matches.json", matches},
+		{"messages.json", messages},
+	})
+	if err != nil {
+		log.Printf("Failed to build the export archive : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditUserDataExported, models.AuditTargetUser, user.ID.String(), nil)
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="cat-socialx-export-%s.zip"`, time.Now().UTC().Format("20060102T150405Z")))
+
+	return c.Send(archive)
+}
+
+// DeleteAccount anonymizes the user right away, the row and what's left of
+// the data is purged once the grace period ends. Accounts created through an
+// OpenID provider set a password with the reset flow first.
+func (i *V1Repository) DeleteAccount(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	deleteRequest := &models.DeleteAccountRequest{}
+
+	if err := c.BodyParser(deleteRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(deleteRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil || user.DeletedAt != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "user not found",
+		})
+	}
+
+	if err := utils.ComparePasswords(user.Password, deleteRequest.Password); err != nil {
+		log.Printf("Failed to compare password : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "password is incorrect",
+		})
+	}
+
+	purgeAfter := time.Now().Add(i.Config.Accounts.DeletionGrace)
+
+	withdrawn, err := i.Repositories.AnonymizeUser(user.ID, purgeAfter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Println("User is already deleted")
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error":   fiber.ErrNotFound.Message,
+				"message": "user not found",
+			})
+		}
+
+		log.Printf("Failed to delete the account : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditUserDeleted, models.AuditTargetUser, user.ID.String(), map[string]interface{}{
+		"purgeAfter":       purgeAfter.UTC().Format(time.RFC3339),
+		"withdrawnMatches": len(withdrawn),
+	})
+
+	for _, match := range withdrawn {
+		if match.RecipientID == user.ID {
+			continue
+		}
+
+		i.publishEvent(&models.Event{
+			Type:        models.EventMatchWithdrawn,
+			RecipientID: match.RecipientID,
+			ActorID:     user.ID,
+			Payload:     match.CatMatch,
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "account deleted",
+		"data": fiber.Map{
+			"purgeAfter": purgeAfter,
+		},
+	})
+}
+
+type jsonFile struct {
+	name string
+	data interface{}
+}
+
+func zipJSONFiles(files []jsonFile) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+
+	for _, file := range files {
+		f, err := w.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
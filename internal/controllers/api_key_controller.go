@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) CreateAPIKey(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if ferr := i.requireVerifiedEmail(principal.UserID); ferr != nil {
+		log.Printf("Email of the user is not verified : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	keyRequest := &models.NewAPIKeyRequest{}
+
+	if err := c.BodyParser(keyRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(keyRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	count, err := i.Repositories.CountActiveAPIKeys(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to count the api keys : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if max := i.Config.APIKeys.MaxPerUser; count >= max {
+		log.Printf("User has too many api keys : %d", count)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   fiber.ErrConflict.Message,
+			"message": fmt.Sprintf("you can have at most %d active api keys, revoke one first", max),
+		})
+	}
+
+	key, prefix, keyHash, err := utils.GenerateAPIKey()
+	if err != nil {
+		log.Printf("Failed to generate the api key : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	apiKey := models.APIKey{
+		ID:        uuid.New(),
+		UserID:    principal.UserID,
+		Name:      keyRequest.Name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    uniqueScopes(keyRequest.Scopes),
+		CreatedAt: time.Now(),
+	}
+
+	if keyRequest.ExpiresInDays != nil {
+		expiresAt := apiKey.CreatedAt.AddDate(0, 0, *keyRequest.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := i.Repositories.CreateAPIKey(&apiKey); err != nil {
+		log.Printf("Failed create new api key : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &principal.UserID, models.AuditAPIKeyCreated, models.AuditTargetAPIKey, apiKey.ID.String(), map[string]interface{}{
+		"name":   apiKey.Name,
+		"scopes": apiKey.Scopes,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "store the key now, it won't be shown again",
+		"data": models.NewAPIKeyResponse{
+			APIKey: apiKey,
+			Key:    key,
+		},
+	})
+}
+
+func (i *V1Repository) GetAPIKeys(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	keys, err := i.Repositories.GetAPIKeysByUserId(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get the api keys : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    keys,
+	})
+}
+
+func (i *V1Repository) RevokeAPIKey(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	keyId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	revoked, err := i.Repositories.RevokeAPIKey(keyId, principal.UserID)
+	if err != nil {
+		log.Printf("Failed to revoke the api key : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if !revoked {
+		log.Println("Api key not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "api key with this ID not found",
+		})
+	}
+
+	i.recordAudit(c, &principal.UserID, models.AuditAPIKeyRevoked, models.AuditTargetAPIKey, keyId.String(), nil)
+
+	return c.JSON(fiber.Map{
+		"message": "api key revoked",
+	})
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+
+	for _, scope := range scopes {
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/audit"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+	// An export is capped so a broad filter can't load the whole table.
+	maxAuditExportSize = 10000
+)
+
+// recordAudit adds the client of the request to the audit entry.
+func (i *V1Repository) recordAudit(c *fiber.Ctx, actorId *uuid.UUID, action string, targetType string, targetId string, metadata map[string]interface{}) {
+	i.Audit.Record(audit.Entry{
+		ActorID:    actorId,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetId,
+		IP:         c.IP(),
+		UserAgent:  truncate(c.Get(fiber.HeaderUserAgent), 512),
+		Metadata:   metadata,
+	})
+}
+
+// GetAuditEvents lists the audit trail for admins, as JSON or, with
+// format=csv, as a CSV download.
+func (i *V1Repository) GetAuditEvents(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if ferr := i.requireAdmin(principal.UserID); ferr != nil {
+		log.Printf("User is not an admin : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	export := strings.EqualFold(c.Query("format"), "csv")
+
+	filter, ferr := auditEventFilter(c, export)
+	if ferr != nil {
+		log.Printf("Invalid audit filter : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	events, total, err := i.Repositories.GetAuditEvents(filter)
+	if err != nil {
+		log.Printf("Failed to get the audit events : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if !export {
+		return c.JSON(fiber.Map{
+			"message": "success",
+			"data":    events,
+			"meta": fiber.Map{
+				"total":  total,
+				"limit":  filter.Limit,
+				"offset": filter.Offset,
+			},
+		})
+	}
+
+	i.recordAudit(c, &principal.UserID, models.AuditAdminAuditExported, "", "", map[string]interface{}{
+		"query": string(c.Request().URI().QueryString()),
+		"rows":  len(events),
+	})
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="audit-events-%s.csv"`, time.Now().UTC().Format("20060102T150405Z")))
+
+	w := csv.NewWriter(c)
+	if err := w.Write([]string{"id", "created_at", "actor_id", "action", "target_type", "target_id", "ip", "user_agent", "metadata"}); err != nil {
+		return err
+	}
+
+	for _, e := range events {
+		actorId := ""
+		if e.ActorID != nil {
+			actorId = e.ActorID.String()
+		}
+
+		record := []string{
+			e.ID.String(),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			actorId,
+			e.Action,
+			e.TargetType,
+			e.TargetID,
+			e.IP,
+			csvSafe(e.UserAgent),
+			string(e.Metadata),
+		}
+
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+func auditEventFilter(c *fiber.Ctx, export bool) (models.AuditEventFilter, *fiber.Error) {
+	filter := models.AuditEventFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+		TargetID:   c.Query("targetId"),
+		IP:         c.Query("ip"),
+		Limit:      defaultAuditPageSize,
+	}
+
+	if actor := c.Query("actorId"); actor != "" {
+		actorId, err := uuid.Parse(actor)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "actorId must be a UUID")
+		}
+		filter.ActorID = &actorId
+	}
+
+	for _, param := range []struct {
+		name string
+		dest **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(param.name)
+		if value == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, param.name+" must be an RFC 3339 time")
+		}
+		*param.dest = &t
+	}
+
+	maxLimit := maxAuditPageSize
+	if export {
+		filter.Limit = maxAuditExportSize
+		maxLimit = maxAuditExportSize
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxLimit {
+			return filter, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("limit must be a number between 1 and %d", maxLimit))
+		}
+		filter.Limit = limit
+	}
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "offset must be a positive number")
+		}
+		filter.Offset = offset
+	}
+
+	return filter, nil
+}
+
+// requireAdmin is checked against the database, a role change applies to the
+// tokens already issued.
+func (i *V1Repository) requireAdmin(userId uuid.UUID) *fiber.Error {
+	user, err := i.Repositories.GetUserByID(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	if user.UserRole != models.UserRoleAdmin {
+		return fiber.NewError(fiber.StatusForbidden, "permission denied, only admins can access this resource")
+	}
+
+	return nil
+}
+
+// csvSafe keeps spreadsheet apps from running a user agent as a formula.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
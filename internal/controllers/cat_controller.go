@@ -15,24 +15,20 @@ import (
 )
 
 func (i *V1Repository) AddNewCat(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
-	expires := claims.Expires
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":   fiber.ErrUnauthorized.Message,
-			"message": err.Error(),
+	if ferr := i.requireVerifiedEmail(principal.UserID); ferr != nil {
+		log.Printf("Email of the user is not verified : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
 		})
 	}
 
@@ -58,7 +54,7 @@ func (i *V1Repository) AddNewCat(c *fiber.Ctx) error {
 		})
 	}
 
-	userID := claims.UserID
+	userID := principal.UserID
 
 	cat := &models.Cat{}
 	cat.ID = uuid.New()
@@ -69,6 +65,8 @@ func (i *V1Repository) AddNewCat(c *fiber.Ctx) error {
 	cat.AgeInMonth = newCat.AgeInMonth
 	cat.ImageUrls = newCat.ImageUrls
 	cat.Description = newCat.Description
+	cat.SireID = newCat.SireID
+	cat.DamID = newCat.DamID
 	cat.HasMatched = false
 	cat.CreatedAt = time.Now()
 
@@ -80,6 +78,14 @@ func (i *V1Repository) AddNewCat(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := i.checkCatParents(cat.ID, cat.SireID, cat.DamID); err != nil {
+		log.Printf("Cat parents doesn't pass validation : %+v", err)
+		return c.Status(err.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(err.Code).Message,
+			"message": err.Message,
+		})
+	}
+
 	log.Printf("Data to add for Cat : %+v", cat)
 
 	if err := i.Repositories.CreateCat(cat); err != nil {
@@ -100,27 +106,6 @@ func (i *V1Repository) AddNewCat(c *fiber.Ctx) error {
 }
 
 func (i *V1Repository) GetCats(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
-	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":   fiber.ErrUnauthorized.Message,
-			"message": err.Error(),
-		})
-	}
-
 	query := ""
 
 	id := c.Query("id")
@@ -188,9 +173,9 @@ func (i *V1Repository) GetCats(c *fiber.Ctx) error {
 	}
 
 	if ownedStr == "true" || ownedStr == "false" {
-		claims, err := utils.ExtractTokenMetadata(c)
+		principal, err := utils.CurrentPrincipal(c)
 		if err == nil {
-			userID := claims.UserID.String()
+			userID := principal.UserID.String()
 			query += fmt.Sprintf(" AND user_id = '%s'", userID)
 		}
 	}
@@ -228,6 +213,27 @@ func (i *V1Repository) GetCats(c *fiber.Ctx) error {
 
 	cats = append(cats, res...)
 
+	// Detail view of a single cat also lists the litters it parented.
+	if id != "" {
+		for idx := range cats {
+			catId, err := uuid.Parse(cats[idx].ID)
+			if err != nil {
+				continue
+			}
+
+			litters, err := i.Repositories.GetLittersByParentId(catId)
+			if err != nil {
+				log.Printf("Failed to get cat litters : %+v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   fiber.ErrInternalServerError.Message,
+					"message": err.Error(),
+				})
+			}
+
+			cats[idx].Litters = litters
+		}
+	}
+
 	log.Printf("Cats data : %+v", cats)
 
 	return c.JSON(fiber.Map{
@@ -237,22 +243,9 @@ func (i *V1Repository) GetCats(c *fiber.Ctx) error {
 }
 
 func (i *V1Repository) UpdateCat(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
-
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
@@ -281,7 +274,7 @@ func (i *V1Repository) UpdateCat(c *fiber.Ctx) error {
 		})
 	}
 
-	userID := claims.UserID
+	userID := principal.UserID
 
 	if foundedCat[0].UserID == userID {
 		cat_update_request := &models.CatUpdateRequest{}
@@ -308,6 +301,22 @@ func (i *V1Repository) UpdateCat(c *fiber.Ctx) error {
 			})
 		}
 
+		if (cat_update_request.ClearSire && cat_update_request.SireID != nil) || (cat_update_request.ClearDam && cat_update_request.DamID != nil) {
+			log.Println("Cat parent is both set and cleared")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "a parent cant be set and cleared in the same request",
+			})
+		}
+
+		if err := i.checkCatParents(foundedCat[0].ID, cat_update_request.SireID, cat_update_request.DamID); err != nil {
+			log.Printf("Cat parents doesn't pass validation : %+v", err)
+			return c.Status(err.Code).JSON(fiber.Map{
+				"error":   fiber.NewError(err.Code).Message,
+				"message": err.Message,
+			})
+		}
+
 		if err := i.Repositories.UpdateCat(foundedCat[0].ID, cat_update_request); err != nil {
 			log.Printf("Failed update cat : %+v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -331,28 +340,16 @@ func (i *V1Repository) UpdateCat(c *fiber.Ctx) error {
 }
 
 func (i *V1Repository) DeleteCat(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
-	userID := claims.UserID.String()
+	userID := principal.UserID.String()
 
 	catID := c.Params("id")
 
@@ -364,6 +361,8 @@ func (i *V1Repository) DeleteCat(c *fiber.Ctx) error {
 		})
 	}
 
+	i.recordAudit(c, &principal.UserID, models.AuditCatDeleted, models.AuditTargetCat, catID, nil)
+
 	return c.JSON(fiber.Map{
 		"error": false,
 		"msg":   "Deletion successfull",
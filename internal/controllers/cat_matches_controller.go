@@ -11,25 +11,22 @@ import (
 )
 
 func (i *V1Repository) CreateCatMatch(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
-	expires := claims.Expires
-	userId := claims.UserID
+	userId := principal.UserID
 
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error":   fiber.ErrUnauthorized.Message,
-			"message": err.Error(),
+	if ferr := i.requireVerifiedEmail(principal.UserID); ferr != nil {
+		log.Printf("Email of the user is not verified : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
 		})
 	}
 
@@ -146,6 +143,18 @@ func (i *V1Repository) CreateCatMatch(c *fiber.Ctx) error {
 	catmatch.Message = catmatch_request.Message
 	catmatch.Status = "pending"
 
+	coefficient, err := i.matchInbreedingCoefficient(issuerCat[0].ID, matchcat[0].ID)
+	if err != nil {
+		log.Printf("Failed to compute the inbreeding coefficient : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catmatch.InbreedingCoefficient = coefficient
+	catmatch.InbreedingWarning = inbreedingWarning(coefficient)
+
 	currentTime := time.Now().Format(time.RFC3339)
 	cm_createdAt, err := time.Parse(time.RFC3339, currentTime)
 	if err != nil {
@@ -169,7 +178,7 @@ func (i *V1Repository) CreateCatMatch(c *fiber.Ctx) error {
 
 	log.Printf("Cat match data to add : %+v", catmatch)
 
-	if err := PublishToRabbitMQ(catmatch); err != nil {
+	if err := PublishToRabbitMQ(i.Config.RabbitMQ, catmatch); err != nil {
 		log.Printf("Failed to publish to rabbitmq : %+v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   fiber.ErrInternalServerError.Message,
@@ -185,6 +194,14 @@ func (i *V1Repository) CreateCatMatch(c *fiber.Ctx) error {
 		})
 	}
 
+	i.publishEvent(&models.Event{
+		Type:        models.EventMatchRequested,
+		RecipientID: matchcat[0].UserID,
+		ActorID:     userId,
+		Payload:     catmatch,
+		CreatedAt:   catmatch.CreatedAt,
+	})
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"message": "success",
 		"data":    catmatch,
@@ -192,28 +209,17 @@ func (i *V1Repository) CreateCatMatch(c *fiber.Ctx) error {
 }
 
 func (i *V1Repository) GetCatMatchRequests(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	userId := claims.UserID
-	expires := claims.Expires
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
+	userId := principal.UserID
+
 	cats, err := i.Repositories.GetCatsByUserId(userId)
 	if err != nil {
 		log.Printf("Failed to get cats data by userID : %+v", err )
@@ -240,6 +246,27 @@ func (i *V1Repository) GetCatMatchRequests(c *fiber.Ctx) error {
 		userCatMatches = append(userCatMatches, catMatches...)
 	}
 
+	// The pedigrees of every cat in the list are read in one query.
+	pedigreeCatIds := []uuid.UUID{}
+	for _, catMatch := range userCatMatches {
+		pedigreeCatIds = append(pedigreeCatIds, catMatch.UserCatDetail.ID, catMatch.MatchCatDetail.ID)
+	}
+
+	ancestors, err := i.Repositories.GetCatsAncestors(pedigreeCatIds, matchInbreedingGenerations)
+	if err != nil {
+		log.Printf("Failed to compute the inbreeding coefficient : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	for idx := range userCatMatches {
+		coefficient := pairInbreedingCoefficient(userCatMatches[idx].UserCatDetail.ID, userCatMatches[idx].MatchCatDetail.ID, ancestors)
+		userCatMatches[idx].InbreedingCoefficient = coefficient
+		userCatMatches[idx].InbreedingWarning = inbreedingWarning(coefficient)
+	}
+
 	log.Printf("CatMatches : %v", userCatMatches)
 
 	return c.JSON(fiber.Map{
@@ -249,28 +276,17 @@ func (i *V1Repository) GetCatMatchRequests(c *fiber.Ctx) error {
 }
 
 func (i *V1Repository) ApproveCatMatch(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-	userId := claims.UserID
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
+	userId := principal.UserID
+
 	updateRequest := &models.CatMatchUpdateRequest{}
 
 	if err := c.BodyParser(updateRequest); err != nil {
@@ -352,6 +368,48 @@ func (i *V1Repository) ApproveCatMatch(c *fiber.Ctx) error {
 		})
 	}
 
+	if updateRequest.RequireVaccinations {
+		for _, cat := range []models.Cats{issuerCat[0], matchCat[0]} {
+			// The records of the other owner's cat are only read once shared
+			// with this match.
+			if cat.UserID != userId {
+				shared, err := i.Repositories.IsHealthRecordsShared(updateRequest.ID, cat.ID)
+				if err != nil {
+					log.Printf("Failed to check the health record sharing : %+v", err)
+					return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+						"error":   fiber.ErrInternalServerError.Message,
+						"message": err.Error(),
+					})
+				}
+
+				if !shared {
+					log.Printf("Cat %+v health records are not shared with the match", cat.ID)
+					return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+						"error":   fiber.ErrBadRequest.Message,
+						"message": "the health records of " + cat.Name + " are not shared with this match",
+					})
+				}
+			}
+
+			upToDate, err := i.Repositories.HasUpToDateVaccinations(cat.ID)
+			if err != nil {
+				log.Printf("Failed to check cat vaccinations : %+v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   fiber.ErrInternalServerError.Message,
+					"message": err.Error(),
+				})
+			}
+
+			if !upToDate {
+				log.Printf("Cat %+v vaccinations are not up to date", cat.ID)
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error":   fiber.ErrBadRequest.Message,
+					"message": "vaccinations of " + cat.Name + " are not up to date",
+				})
+			}
+		}
+	}
+
 	if err := i.Repositories.UpdateCatMatch(updateRequest.ID, "approved"); err != nil {
 		log.Printf("Failed to update CatMatch status : %+v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -414,34 +472,34 @@ func (i *V1Repository) ApproveCatMatch(c *fiber.Ctx) error {
 		}
 	}
 
+	cat_match[0].Status = "approved"
+	i.publishEvent(&models.Event{
+		Type:        models.EventMatchApproved,
+		RecipientID: issuerCat[0].UserID,
+		ActorID:     userId,
+		Payload:     cat_match[0],
+		CreatedAt:   time.Now(),
+	})
+
+	i.recordAudit(c, &userId, models.AuditMatchApproved, models.AuditTargetMatch, cat_match[0].ID.String(), nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "success accepted cat match",
 	})
 }
 
 func (i *V1Repository) RejectCatMatch(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-	userId := claims.UserID
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
+	userId := principal.UserID
+
 	updateRequest := &models.CatMatchUpdateRequest{}
 
 	if err := c.BodyParser(updateRequest); err != nil {
@@ -531,34 +589,33 @@ func (i *V1Repository) RejectCatMatch(c *fiber.Ctx) error {
 		})
 	}
 
+	cat_match[0].Status = "rejected"
+	i.publishEvent(&models.Event{
+		Type:        models.EventMatchRejected,
+		RecipientID: issuerCat[0].UserID,
+		ActorID:     userId,
+		Payload:     cat_match[0],
+		CreatedAt:   time.Now(),
+	})
+
+	i.recordAudit(c, &userId, models.AuditMatchRejected, models.AuditTargetMatch, cat_match[0].ID.String(), nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "success rejected cat match",
 	})
 }
 
 func (i *V1Repository) DeleteCatMatch(c *fiber.Ctx) error {
-	now := time.Now().Unix()
-
-	claims, err := utils.ExtractTokenMetadata(c)
+	principal, err := utils.CurrentPrincipal(c)
 	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	expires := claims.Expires
-	userId := claims.UserID
-
-	if now > expires {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+		log.Printf("Failed to get the principal : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": err.Error(),
 		})
 	}
 
+	userId := principal.UserID
 
 	id := c.Params("id")
 	catMatchId, err := uuid.Parse(id)
@@ -615,6 +672,15 @@ func (i *V1Repository) DeleteCatMatch(c *fiber.Ctx) error {
 		})
 	}
 
+	matchCat, err := i.Repositories.GetCatById(cat_match[0].CatMatchID)
+	if err != nil {
+		log.Printf("Failed to get Match Cat data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
 	if err := i.Repositories.DeleteCatMatchById(catMatchId); err != nil {
 		log.Printf("Failed to delete CatMatch data : %+v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -623,6 +689,17 @@ func (i *V1Repository) DeleteCatMatch(c *fiber.Ctx) error {
 		})
 	}
 
+	if len(matchCat) > 0 {
+		cat_match[0].Status = "withdrawn"
+		i.publishEvent(&models.Event{
+			Type:        models.EventMatchWithdrawn,
+			RecipientID: matchCat[0].UserID,
+			ActorID:     userId,
+			Payload:     cat_match[0],
+			CreatedAt:   time.Now(),
+		})
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"id":      id,
 		"message": "success deleted cat match",
@@ -0,0 +1,280 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+const (
+	defaultChatPageSize = 50
+	maxChatPageSize     = 100
+)
+
+// chatStore is the part of the repositories reading and posting messages
+// needs.
+type chatStore interface {
+	catMatchPartyStore
+	GetChatMessages(catMatchId uuid.UUID, before *uuid.UUID, limit int) ([]models.ChatMessage, error)
+	CreateChatMessage(m *models.ChatMessage) error
+}
+
+func (i *V1Repository) GetCatMatchMessages(c *fiber.Ctx) error {
+	return getCatMatchMessages(c, i.Repositories)
+}
+
+// The conversation of a withdrawn or rejected match stays readable.
+func getCatMatchMessages(c *fiber.Ctx, store chatStore) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, _, _, ferr := findCatMatchParties(store, c.Params("id"), principal.UserID, true)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	limit := defaultChatPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxChatPageSize {
+			log.Printf("Invalid limit query : %+v", limitStr)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "limit must be a number between 1 and " + strconv.Itoa(maxChatPageSize),
+			})
+		}
+	}
+
+	var before *uuid.UUID
+	if beforeStr := c.Query("before"); beforeStr != "" {
+		beforeId, err := uuid.Parse(beforeStr)
+		if err != nil {
+			log.Printf("Invalid before query : %+v", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": err.Error(),
+			})
+		}
+		before = &beforeId
+	}
+
+	messages, err := store.GetChatMessages(catMatch.ID, before, limit)
+	if errors.Is(err, repositories.ErrChatCursorNotFound) {
+		log.Printf("Invalid before query : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to get chat messages : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	var nextCursor *uuid.UUID
+	if len(messages) == limit {
+		nextCursor = &messages[len(messages)-1].ID
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    messages,
+		"meta": fiber.Map{
+			"locked":     isConversationLocked(catMatch),
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+func (i *V1Repository) SendCatMatchMessage(c *fiber.Ctx) error {
+	return sendCatMatchMessage(c, i.Repositories, i.publishEvent)
+}
+
+func sendCatMatchMessage(c *fiber.Ctx, store chatStore, publish func(e *models.Event)) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, _, otherCat, ferr := findCatMatchParties(store, c.Params("id"), principal.UserID, true)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if isConversationLocked(catMatch) {
+		log.Println("Conversation of this CatMatch is locked")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   fiber.ErrForbidden.Message,
+			"message": "this conversation is locked because the match was rejected or withdrawn",
+		})
+	}
+
+	messageRequest := &models.ChatMessageRequest{}
+
+	if err := c.BodyParser(messageRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(messageRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	message := &models.ChatMessage{}
+	message.ID = uuid.New()
+	message.CatMatchID = catMatch.ID
+	message.SenderID = principal.UserID
+	message.Body = messageRequest.Body
+	message.CreatedAt = time.Now()
+
+	if err := store.CreateChatMessage(message); err != nil {
+		log.Printf("Failed create new chat message : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	event := &models.Event{
+		Type:        models.EventChatMessage,
+		RecipientID: otherCat.UserID,
+		ActorID:     principal.UserID,
+		Payload:     message,
+		CreatedAt:   message.CreatedAt,
+	}
+
+	publish(event)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    message,
+	})
+}
+
+func (i *V1Repository) MarkCatMatchMessagesRead(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, _, _, ferr := findCatMatchParties(i.Repositories, c.Params("id"), principal.UserID, true)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	marked, err := i.Repositories.MarkChatMessagesRead(catMatch.ID, principal.UserID)
+	if err != nil {
+		log.Printf("Failed to mark chat messages as read : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data": fiber.Map{
+			"marked": marked,
+		},
+	})
+}
+
+func (i *V1Repository) DeleteCatMatchMessage(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, _, _, ferr := findCatMatchParties(i.Repositories, c.Params("id"), principal.UserID, true)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if isConversationLocked(catMatch) {
+		log.Println("Conversation of this CatMatch is locked")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   fiber.ErrForbidden.Message,
+			"message": "this conversation is locked because the match was rejected or withdrawn",
+		})
+	}
+
+	messageId, err := uuid.Parse(c.Params("messageId"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	// Only the sender can delete a message, other ones are reported as not found.
+	if err := i.Repositories.DeleteChatMessage(messageId, catMatch.ID, principal.UserID); err != nil {
+		log.Printf("Failed to delete chat message : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"id":      messageId,
+		"message": "success deleted message",
+	})
+}
+
+func isConversationLocked(catMatch models.CatMatch) bool {
+	return catMatch.Status == "rejected" || catMatch.Status == "withdrawn" || catMatch.DeletedAt != nil
+}
@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// fakeChatStore keeps one match and its messages in memory.
+type fakeChatStore struct {
+	match    models.CatMatch
+	cats     map[uuid.UUID]models.Cats
+	messages []models.ChatMessage
+}
+
+// newWithdrawnThread returns a withdrawn match between the cats of two users
+// with one message in its conversation.
+func newWithdrawnThread(issuer uuid.UUID, receiver uuid.UUID) *fakeChatStore {
+	issuerCat := models.Cats{ID: uuid.New(), UserID: issuer}
+	matchCat := models.Cats{ID: uuid.New(), UserID: receiver}
+	deletedAt := time.Now()
+
+	s := &fakeChatStore{
+		match: models.CatMatch{ID: uuid.New(), CatIssuerID: issuerCat.ID, CatMatchID: matchCat.ID, Status: "withdrawn", DeletedAt: &deletedAt},
+		cats:  map[uuid.UUID]models.Cats{issuerCat.ID: issuerCat, matchCat.ID: matchCat},
+	}
+	s.messages = []models.ChatMessage{{ID: uuid.New(), CatMatchID: s.match.ID, SenderID: issuer, Body: "hello", CreatedAt: deletedAt.Add(-time.Hour)}}
+
+	return s
+}
+
+func (s *fakeChatStore) GetCatMatchById(id uuid.UUID) ([]models.CatMatch, error) {
+	if id != s.match.ID || s.match.DeletedAt != nil {
+		return []models.CatMatch{}, nil
+	}
+
+	return []models.CatMatch{s.match}, nil
+}
+
+func (s *fakeChatStore) GetCatMatchByIdIncludingWithdrawn(id uuid.UUID) ([]models.CatMatch, error) {
+	if id != s.match.ID {
+		return []models.CatMatch{}, nil
+	}
+
+	return []models.CatMatch{s.match}, nil
+}
+
+func (s *fakeChatStore) GetCatById(id uuid.UUID) ([]models.Cats, error) {
+	cat, ok := s.cats[id]
+	if !ok {
+		return []models.Cats{}, nil
+	}
+
+	return []models.Cats{cat}, nil
+}
+
+func (s *fakeChatStore) GetChatMessages(catMatchId uuid.UUID, before *uuid.UUID, limit int) ([]models.ChatMessage, error) {
+	if before != nil {
+		found := false
+		for _, m := range s.messages {
+			found = found || (m.ID == *before && m.CatMatchID == catMatchId)
+		}
+		if !found {
+			return nil, repositories.ErrChatCursorNotFound
+		}
+	}
+
+	messages := []models.ChatMessage{}
+	for _, m := range s.messages {
+		if m.CatMatchID == catMatchId && (before == nil || m.ID != *before) {
+			messages = append(messages, m)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *fakeChatStore) CreateChatMessage(m *models.ChatMessage) error {
+	s.messages = append(s.messages, *m)
+	return nil
+}
+
+// chatApp serves the chat of the store for the given user.
+func chatApp(store chatStore, userId uuid.UUID) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		utils.SetPrincipal(c, &models.Principal{UserID: userId})
+		return c.Next()
+	})
+	app.Get("/v1/cat/match/:id/messages", func(c *fiber.Ctx) error {
+		return getCatMatchMessages(c, store)
+	})
+	app.Post("/v1/cat/match/:id/messages", func(c *fiber.Ctx) error {
+		return sendCatMatchMessage(c, store, func(e *models.Event) {})
+	})
+
+	return app
+}
+
+func TestWithdrawnThreadStaysReadable(t *testing.T) {
+	issuer := uuid.New()
+	store := newWithdrawnThread(issuer, uuid.New())
+
+	resp, err := chatApp(store, issuer).Test(httptest.NewRequest(fiber.MethodGet, "/v1/cat/match/"+store.match.ID.String()+"/messages", nil))
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+
+	body := struct {
+		Data []models.ChatMessage `json:"data"`
+		Meta struct {
+			Locked bool `json:"locked"`
+		} `json:"meta"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding the response : %v", err)
+	}
+
+	if len(body.Data) != 1 || body.Data[0].Body != "hello" {
+		t.Errorf("messages = %+v, want the message of the thread", body.Data)
+	}
+	if !body.Meta.Locked {
+		t.Error("meta.locked = false, want the withdrawn thread locked")
+	}
+}
+
+func TestWithdrawnThreadRefusesNewMessages(t *testing.T) {
+	receiver := uuid.New()
+	store := newWithdrawnThread(uuid.New(), receiver)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/v1/cat/match/"+store.match.ID.String()+"/messages", strings.NewReader(`{"body":"are you there?"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := chatApp(store, receiver).Test(req)
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusForbidden || !strings.Contains(string(body), "locked") {
+		t.Errorf("got %d %s, want 403 with the locked error", resp.StatusCode, body)
+	}
+	if len(store.messages) != 1 {
+		t.Errorf("stored %d messages, want the thread unchanged", len(store.messages))
+	}
+}
+
+func TestGetMessagesRejectsCursorOfAnotherThread(t *testing.T) {
+	issuer := uuid.New()
+	store := newWithdrawnThread(issuer, uuid.New())
+
+	target := "/v1/cat/match/" + store.match.ID.String() + "/messages?before=" + uuid.NewString()
+	resp, err := chatApp(store, issuer).Test(httptest.NewRequest(fiber.MethodGet, target, nil))
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusBadRequest {
+		t.Errorf("status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestFindCatMatchPartiesHidesWithdrawnMatch(t *testing.T) {
+	issuer := uuid.New()
+	store := newWithdrawnThread(issuer, uuid.New())
+
+	if _, _, _, ferr := findCatMatchParties(store, store.match.ID.String(), issuer, false); ferr == nil || ferr.Code != fiber.StatusNotFound {
+		t.Errorf("got %v, want 404 without includeWithdrawn", ferr)
+	}
+
+	if _, _, _, ferr := findCatMatchParties(store, store.match.ID.String(), uuid.New(), true); ferr == nil || ferr.Code != fiber.StatusForbidden {
+		t.Errorf("got %v, want 403 for a user outside the match", ferr)
+	}
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// GetHealth is the probe of load balancers and orchestrators, it fails while
+// the database can't be reached.
+func (i *V1Repository) GetHealth(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*2)
+	defer cancel()
+
+	if err := i.Repositories.PingDatabase(ctx); err != nil {
+		log.Printf("Health check failed to ping the database : %+v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":   "unavailable",
+			"database": "unreachable",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":   "ok",
+		"database": "ok",
+	})
+}
+
+// GetDatabaseStats shows the connection pool to admins, a growing wait count
+// means the pool is too small for the load.
+func (i *V1Repository) GetDatabaseStats(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if ferr := i.requireAdmin(principal.UserID); ferr != nil {
+		log.Printf("User is not an admin : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    i.Repositories.GetDatabaseStats(),
+	})
+}
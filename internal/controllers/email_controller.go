@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"log"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/events"
+	"github.com/ravenocx/cat-socialx/internal/mailer"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+var emailTemplates = map[string]string{
+	models.EventMatchRequested: mailer.TemplateMatchRequested,
+	models.EventMatchApproved:  mailer.TemplateMatchApproved,
+	models.EventMatchRejected:  mailer.TemplateMatchRejected,
+}
+
+// EmailNotifier emails the recipient of match lifecycle events through the
+// mail queue, unless the recipient unsubscribed from that email.
+func EmailNotifier(repo *repositories.DatabaseRepositories, queue *mailer.Queue, baseURL string, cfg config.Mail) events.Handler {
+	return func(e models.Event) {
+		template, ok := emailTemplates[e.Type]
+		if !ok {
+			return
+		}
+
+		var catMatch models.CatMatch
+		switch payload := e.Payload.(type) {
+		case models.CatMatch:
+			catMatch = payload
+		case *models.CatMatch:
+			catMatch = *payload
+		default:
+			return
+		}
+
+		// Lookups hit the database, keep them out of the request goroutine.
+		go func() {
+			msg, err := buildMatchEmail(repo, cfg, e, catMatch, template, baseURL)
+			if err != nil {
+				log.Printf("Failed to build match email : %+v", err)
+				return
+			}
+
+			if msg == nil {
+				return
+			}
+
+			if err := queue.Enqueue(*msg); err != nil {
+				log.Printf("Failed to enqueue match email : %+v", err)
+			}
+		}()
+	}
+}
+
+func buildMatchEmail(repo *repositories.DatabaseRepositories, cfg config.Mail, e models.Event, catMatch models.CatMatch, template string, baseURL string) (*mailer.Message, error) {
+	emailType := models.EmailPreferencePrefix + e.Type
+
+	enabled, err := repo.IsNotificationEnabled(e.RecipientID, emailType)
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	recipient, err := repo.GetUserByID(e.RecipientID)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := repo.GetUserByID(e.ActorID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := mailer.TemplateData{
+		RecipientName: recipient.Name,
+		ActorName:     actor.Name,
+		Message:       catMatch.Message,
+		ActionURL:     baseURL + "/v1/cat/match",
+		UnsubscribeURL: baseURL + "/v1/email/unsubscribe?token=" +
+			url.QueryEscape(utils.GenerateUnsubscribeToken(cfg, recipient.ID, emailType)),
+	}
+
+	if cat, err := repo.GetCatById(catMatch.CatIssuerID); err == nil && len(cat) > 0 {
+		data.IssuerCatName = cat[0].Name
+	}
+
+	if cat, err := repo.GetCatById(catMatch.CatMatchID); err == nil && len(cat) > 0 {
+		data.MatchCatName = cat[0].Name
+	}
+
+	msg, err := mailer.Render(template, recipient.Email, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+func (i *V1Repository) UnsubscribeEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+
+	userId, emailType, err := utils.ParseUnsubscribeToken(i.Config.Mail, token)
+	if err != nil {
+		log.Printf("Failed to parse the unsubscribe token : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if !isNotificationType(emailType) || userId == uuid.Nil {
+		log.Printf("Unknown email type in unsubscribe token : %+v", emailType)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ErrInvalidUnsubscribeToken.Error(),
+		})
+	}
+
+	preference := models.NotificationPreference{Type: emailType, Enabled: false}
+	if err := i.Repositories.UpsertNotificationPreference(userId, preference); err != nil {
+		log.Printf("Failed to update notification preference : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "you are unsubscribed from these emails",
+	})
+}
@@ -0,0 +1,448 @@
+package controllers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) GetCatHealthRecords(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	cat, ferr := i.findOwnedCat(c.Params("id"), principal.UserID)
+	if ferr != nil {
+		log.Printf("Failed to find the owned cat : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	records, err := i.Repositories.GetHealthRecordsByCatId(cat.ID)
+	if err != nil {
+		log.Printf("Failed to get health records : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    records,
+	})
+}
+
+func (i *V1Repository) AddCatHealthRecord(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	cat, ferr := i.findOwnedCat(c.Params("id"), principal.UserID)
+	if ferr != nil {
+		log.Printf("Failed to find the owned cat : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	record := &models.HealthRecord{}
+	record.ID = uuid.New()
+	record.CatID = cat.ID
+	record.CreatedAt = time.Now()
+
+	if invalid := parseHealthRecordRequest(c, record); invalid != nil {
+		log.Printf("Health record payload is not valid : %+v", invalid)
+		return c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+
+	log.Printf("Health record data to add : %+v", record)
+
+	if err := i.Repositories.CreateHealthRecord(record); err != nil {
+		log.Printf("Failed create new health record : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    record,
+	})
+}
+
+func (i *V1Repository) UpdateCatHealthRecord(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	cat, ferr := i.findOwnedCat(c.Params("id"), principal.UserID)
+	if ferr != nil {
+		log.Printf("Failed to find the owned cat : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	recordId, err := uuid.Parse(c.Params("recordId"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	found, err := i.Repositories.GetHealthRecordById(recordId, cat.ID)
+	if err != nil {
+		log.Printf("Failed to get health record : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if len(found) == 0 {
+		log.Println("Health record not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "health record not found",
+		})
+	}
+
+	record := &found[0]
+	updatedAt := time.Now()
+	record.UpdatedAt = &updatedAt
+
+	if invalid := parseHealthRecordRequest(c, record); invalid != nil {
+		log.Printf("Health record payload is not valid : %+v", invalid)
+		return c.Status(fiber.StatusBadRequest).JSON(invalid)
+	}
+
+	if err := i.Repositories.UpdateHealthRecord(record); err != nil {
+		log.Printf("Failed update health record : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "successfully updated health record",
+		"data":    record,
+	})
+}
+
+func (i *V1Repository) DeleteCatHealthRecord(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	cat, ferr := i.findOwnedCat(c.Params("id"), principal.UserID)
+	if ferr != nil {
+		log.Printf("Failed to find the owned cat : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	recordId, err := uuid.Parse(c.Params("recordId"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.DeleteHealthRecord(recordId, cat.ID); err != nil {
+		log.Printf("Failed to delete health record : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"id":      recordId,
+		"message": "success deleted health record",
+	})
+}
+
+func (i *V1Repository) ShareCatMatchHealthRecords(c *fiber.Ctx) error {
+	return i.toggleCatMatchHealthShare(c, true)
+}
+
+func (i *V1Repository) UnshareCatMatchHealthRecords(c *fiber.Ctx) error {
+	return i.toggleCatMatchHealthShare(c, false)
+}
+
+func (i *V1Repository) toggleCatMatchHealthShare(c *fiber.Ctx, share bool) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, ownCat, _, ferr := findCatMatchParties(i.Repositories, c.Params("id"), principal.UserID, false)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if !share {
+		if err := i.Repositories.UnshareHealthRecords(catMatch.ID, ownCat.ID); err != nil {
+			log.Printf("Failed to unshare health records : %+v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   fiber.ErrInternalServerError.Message,
+				"message": err.Error(),
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"message": "success unshared health records",
+		})
+	}
+
+	if catMatch.Status != "pending" {
+		log.Println("This CatMatch request status is not pending")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "health records can only be shared on a pending match request",
+		})
+	}
+
+	if err := i.Repositories.ShareHealthRecords(catMatch.ID, ownCat.ID, principal.UserID); err != nil {
+		log.Printf("Failed to share health records : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success shared health records",
+	})
+}
+
+func (i *V1Repository) GetCatMatchHealthRecords(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	catMatch, ownCat, otherCat, ferr := findCatMatchParties(i.Repositories, c.Params("id"), principal.UserID, false)
+	if ferr != nil {
+		log.Printf("Failed to find the cat match parties : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	result := []models.SharedHealthRecords{}
+
+	for _, cat := range []models.Cats{ownCat, otherCat} {
+		shared, err := i.Repositories.IsHealthRecordsShared(catMatch.ID, cat.ID)
+		if err != nil {
+			log.Printf("Failed to get health share : %+v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   fiber.ErrInternalServerError.Message,
+				"message": err.Error(),
+			})
+		}
+
+		entry := models.SharedHealthRecords{CatID: cat.ID, Shared: shared, Records: []models.HealthRecord{}}
+
+		// The other party only sees the records after the owner shared them.
+		if shared || cat.ID == ownCat.ID {
+			entry.Records, err = i.Repositories.GetHealthRecordsByCatId(cat.ID)
+			if err != nil {
+				log.Printf("Failed to get health records : %+v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":   fiber.ErrInternalServerError.Message,
+					"message": err.Error(),
+				})
+			}
+		}
+
+		result = append(result, entry)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    result,
+	})
+}
+
+func (i *V1Repository) findOwnedCat(catIdParam string, userId uuid.UUID) (models.Cats, *fiber.Error) {
+	catId, err := uuid.Parse(catIdParam)
+	if err != nil {
+		return models.Cats{}, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	cat, err := i.Repositories.GetCatById(catId)
+	if err != nil {
+		return models.Cats{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(cat) == 0 {
+		return models.Cats{}, fiber.NewError(fiber.StatusNotFound, "cat with this ID not found")
+	}
+
+	if cat[0].UserID != userId {
+		return models.Cats{}, fiber.NewError(fiber.StatusForbidden, "permission denied, only owner can manage this cat")
+	}
+
+	return cat[0], nil
+}
+
+// catMatchPartyStore is the part of the repositories findCatMatchParties needs.
+type catMatchPartyStore interface {
+	GetCatMatchById(id uuid.UUID) ([]models.CatMatch, error)
+	GetCatMatchByIdIncludingWithdrawn(id uuid.UUID) ([]models.CatMatch, error)
+	GetCatById(id uuid.UUID) ([]models.Cats, error)
+}
+
+// findCatMatchParties returns the match together with the cat owned by the user
+// and the cat of the other party. Withdrawn matches are only found when
+// includeWithdrawn is set.
+func findCatMatchParties(store catMatchPartyStore, catMatchIdParam string, userId uuid.UUID, includeWithdrawn bool) (models.CatMatch, models.Cats, models.Cats, *fiber.Error) {
+	catMatchId, err := uuid.Parse(catMatchIdParam)
+	if err != nil {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	var catMatch []models.CatMatch
+	if includeWithdrawn {
+		catMatch, err = store.GetCatMatchByIdIncludingWithdrawn(catMatchId)
+	} else {
+		catMatch, err = store.GetCatMatchById(catMatchId)
+	}
+	if err != nil {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(catMatch) == 0 {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusNotFound, "cat match not found")
+	}
+
+	issuerCat, err := store.GetCatById(catMatch[0].CatIssuerID)
+	if err != nil {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	matchCat, err := store.GetCatById(catMatch[0].CatMatchID)
+	if err != nil {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(issuerCat) == 0 || len(matchCat) == 0 {
+		return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusNotFound, "one of the cat in this match is not found")
+	}
+
+	switch userId {
+	case issuerCat[0].UserID:
+		return catMatch[0], issuerCat[0], matchCat[0], nil
+	case matchCat[0].UserID:
+		return catMatch[0], matchCat[0], issuerCat[0], nil
+	}
+
+	return models.CatMatch{}, models.Cats{}, models.Cats{}, fiber.NewError(fiber.StatusForbidden, "permission denied, you are not part of this match")
+}
+
+// parseHealthRecordRequest fills the record from the body, it returns the 400
+// response when the body isn't valid.
+func parseHealthRecordRequest(c *fiber.Ctx, record *models.HealthRecord) fiber.Map {
+	request := &models.HealthRecordRequest{}
+
+	if err := c.BodyParser(request); err != nil {
+		return badHealthRecordRequest(err.Error())
+	}
+
+	log.Printf("Payload : %+v", request)
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(request); err != nil {
+		return badHealthRecordRequest(utils.ValidatorErrors(err))
+	}
+
+	performedAt, err := time.Parse("2006-01-02", request.PerformedAt)
+	if err != nil {
+		return badHealthRecordRequest(err.Error())
+	}
+
+	var expiresAt *time.Time
+	if request.ExpiresAt != "" {
+		parsed, err := time.Parse("2006-01-02", request.ExpiresAt)
+		if err != nil {
+			return badHealthRecordRequest(err.Error())
+		}
+
+		if parsed.Before(performedAt) {
+			return badHealthRecordRequest("expires date cant be before the performed date")
+		}
+		expiresAt = &parsed
+	}
+
+	record.RecordType = request.RecordType
+	record.Title = request.Title
+	record.Notes = request.Notes
+	record.PerformedAt = performedAt
+	record.ExpiresAt = expiresAt
+	record.DocumentUrls = request.DocumentUrls
+
+	if record.DocumentUrls == nil {
+		record.DocumentUrls = []string{}
+	}
+
+	return nil
+}
+
+func badHealthRecordRequest(message interface{}) fiber.Map {
+	return fiber.Map{
+		"error":   fiber.ErrBadRequest.Message,
+		"message": message,
+	}
+}
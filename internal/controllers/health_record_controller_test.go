@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+func postHealthRecord(t *testing.T, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	app := fiber.New()
+	app.Post("/", func(c *fiber.Ctx) error {
+		if invalid := parseHealthRecordRequest(c, &models.HealthRecord{}); invalid != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(invalid)
+		}
+
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+
+	response := map[string]interface{}{}
+	json.NewDecoder(resp.Body).Decode(&response)
+
+	return resp.StatusCode, response
+}
+
+func TestParseHealthRecordRequestReportsFields(t *testing.T) {
+	status, response := postHealthRecord(t, `{"recordType": "grooming", "performedAt": "2024-13-01"}`)
+	if status != fiber.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", status)
+	}
+
+	fields, ok := response["message"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("message = %v, want the invalid fields", response["message"])
+	}
+
+	for _, field := range []string{"RecordType", "Title", "PerformedAt"} {
+		if _, ok := fields[field]; !ok {
+			t.Errorf("fields = %v, want %s", fields, field)
+		}
+	}
+}
+
+func TestParseHealthRecordRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"recordType": "vaccination", "title": "Rabies", "performedAt": "2024-06-01", "expiresAt": "2025-06-01"}`, fiber.StatusCreated},
+		{"expires before performed", `{"recordType": "vaccination", "title": "Rabies", "performedAt": "2024-06-01", "expiresAt": "2024-05-01"}`, fiber.StatusBadRequest},
+		{"not JSON", `recordType=vaccination`, fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if status, response := postHealthRecord(t, tt.body); status != tt.status {
+			t.Errorf("%s : status = %d, want %d (%v)", tt.name, status, tt.status, response)
+		}
+	}
+}
@@ -2,26 +2,94 @@ package controllers
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/audit"
+	"github.com/ravenocx/cat-socialx/internal/events"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+	"github.com/ravenocx/cat-socialx/internal/loginguard"
+	"github.com/ravenocx/cat-socialx/internal/mailer"
+	"github.com/ravenocx/cat-socialx/internal/oidc"
 	"github.com/ravenocx/cat-socialx/internal/repositories"
 )
 
 type V1Repository struct {
 	Repositories *repositories.DatabaseRepositories
+	Events       *events.Bus
+	Hub          *events.Hub
+	Mailer       *mailer.Queue
+	BaseURL      string
+	LoginGuard   *loginguard.Guard
+	OIDC         *oidc.Registry
+	Audit        *audit.Service
+	Config       *config.Config
+	// Keys sign the access tokens and publish their public part.
+	Keys *jwtkeys.KeySet
 }
 
 type iV1Controller interface {
 	UserSignUp(c *fiber.Ctx) error
 	UserSignIn(c *fiber.Ctx) error
+	VerifyEmail(c *fiber.Ctx) error
+	ResendVerificationEmail(c *fiber.Ctx) error
+	ForgotPassword(c *fiber.Ctx) error
+	ResetPassword(c *fiber.Ctx) error
+	GetProfile(c *fiber.Ctx) error
+	UpdateProfile(c *fiber.Ctx) error
+	ChangePassword(c *fiber.Ctx) error
+	ChangeEmail(c *fiber.Ctx) error
+	ExportUserData(c *fiber.Ctx) error
+	DeleteAccount(c *fiber.Ctx) error
+	GetMFAStatus(c *fiber.Ctx) error
+	EnrollTOTP(c *fiber.Ctx) error
+	ConfirmTOTP(c *fiber.Ctx) error
+	DisableMFA(c *fiber.Ctx) error
+	RegenerateRecoveryCodes(c *fiber.Ctx) error
+	CompleteMFASignIn(c *fiber.Ctx) error
+	CreateAPIKey(c *fiber.Ctx) error
+	GetAPIKeys(c *fiber.Ctx) error
+	RevokeAPIKey(c *fiber.Ctx) error
+	GetOIDCProviders(c *fiber.Ctx) error
+	StartOIDCSignIn(c *fiber.Ctx) error
+	OIDCCallback(c *fiber.Ctx) error
+	LinkOIDCIdentity(c *fiber.Ctx) error
+	GetUserIdentities(c *fiber.Ctx) error
+	UnlinkOIDCIdentity(c *fiber.Ctx) error
+	GetSessions(c *fiber.Ctx) error
+	RevokeSession(c *fiber.Ctx) error
+	GetAuditEvents(c *fiber.Ctx) error
+	GetDatabaseStats(c *fiber.Ctx) error
+	GetHealth(c *fiber.Ctx) error
 	AddNewCat(c *fiber.Ctx) error
 	GetCats(c *fiber.Ctx) error
 	UpdateCat(c *fiber.Ctx) error
 	DeleteCat(c *fiber.Ctx) error
+	GetCatPedigree(c *fiber.Ctx) error
 	CreateCatMatch(c *fiber.Ctx) error
 	GetCatMatchRequests(c *fiber.Ctx) error
 	ApproveCatMatch(c *fiber.Ctx) error
 	RejectCatMatch(c *fiber.Ctx) error
 	DeleteCatMatch(c *fiber.Ctx) error
+	RegisterLitter(c *fiber.Ctx) error
+	GetCatHealthRecords(c *fiber.Ctx) error
+	AddCatHealthRecord(c *fiber.Ctx) error
+	UpdateCatHealthRecord(c *fiber.Ctx) error
+	DeleteCatHealthRecord(c *fiber.Ctx) error
+	ShareCatMatchHealthRecords(c *fiber.Ctx) error
+	UnshareCatMatchHealthRecords(c *fiber.Ctx) error
+	GetCatMatchHealthRecords(c *fiber.Ctx) error
+	GetCatMatchMessages(c *fiber.Ctx) error
+	SendCatMatchMessage(c *fiber.Ctx) error
+	MarkCatMatchMessagesRead(c *fiber.Ctx) error
+	DeleteCatMatchMessage(c *fiber.Ctx) error
+	StreamEvents(c *fiber.Ctx) error
+	GetNotifications(c *fiber.Ctx) error
+	MarkNotificationRead(c *fiber.Ctx) error
+	MarkAllNotificationsRead(c *fiber.Ctx) error
+	GetNotificationPreferences(c *fiber.Ctx) error
+	UpdateNotificationPreferences(c *fiber.Ctx) error
+	UnsubscribeEmail(c *fiber.Ctx) error
 	RenewTokens(c *fiber.Ctx) error
+	GetJWKS(c *fiber.Ctx) error
 }
 
 func New(v1Repository *V1Repository) iV1Controller {
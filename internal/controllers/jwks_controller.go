@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS publishes the public keys that verify our access tokens.
+func (i *V1Repository) GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return c.JSON(i.Keys.JWKS())
+}
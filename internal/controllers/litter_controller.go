@@ -0,0 +1,221 @@
+package controllers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) RegisterLitter(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	userId := principal.UserID
+
+	catMatchId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Failed to parse the catmatch id params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	litterRequest := &models.LitterRequest{}
+
+	if err := c.BodyParser(litterRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("Payload : %+v", litterRequest)
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(litterRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	cat_match, err := i.Repositories.GetCatMatchById(catMatchId)
+	if err != nil {
+		log.Printf("Failed to get CatMatch data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if len(cat_match) == 0 {
+		log.Println("CatMatch data not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "cat match not found",
+		})
+	}
+
+	if cat_match[0].Status != "approved" {
+		log.Println("This CatMatch request status is not approved")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "litter can only be registered for an approved match",
+		})
+	}
+
+	issuerCat, err := i.Repositories.GetCatById(cat_match[0].CatIssuerID)
+	if err != nil {
+		log.Printf("Failed to get issuer cat data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	matchCat, err := i.Repositories.GetCatById(cat_match[0].CatMatchID)
+	if err != nil {
+		log.Printf("Failed to get match cat data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if len(issuerCat) == 0 || len(matchCat) == 0 {
+		log.Println("One of the parent cat is not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "one of the parent cat is not found",
+		})
+	}
+
+	var otherOwnerId uuid.UUID
+
+	switch userId {
+	case issuerCat[0].UserID:
+		otherOwnerId = matchCat[0].UserID
+	case matchCat[0].UserID:
+		otherOwnerId = issuerCat[0].UserID
+	default:
+		log.Println("Neither cat in this CatMatch is owned by the user")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":   fiber.ErrForbidden.Message,
+			"message": "permission denied, only owners of the matched cats can register a litter",
+		})
+	}
+
+	sire, dam := issuerCat[0], matchCat[0]
+	if sire.Sex != "male" {
+		sire, dam = dam, sire
+	}
+
+	bornAt, err := time.Parse("2006-01-02", litterRequest.BornAt)
+	if err != nil {
+		log.Printf("Failed to parse the born date : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if bornAt.After(time.Now()) || bornAt.Before(cat_match[0].CreatedAt.Truncate(24*time.Hour)) {
+		log.Printf("Born date is out of range : %+v", bornAt)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "born date needs to be between the match date and today",
+		})
+	}
+
+	litter := &models.Litter{}
+	litter.ID = uuid.New()
+	litter.CatMatchID = cat_match[0].ID
+	litter.SireID = sire.ID
+	litter.DamID = dam.ID
+	litter.RegisteredBy = userId
+	litter.BornAt = bornAt
+	litter.CreatedAt = time.Now()
+
+	kittens := []models.Cat{}
+
+	for _, kittenRequest := range litterRequest.Kittens {
+		ownerId := userId
+		if kittenRequest.OwnerID != nil {
+			ownerId = *kittenRequest.OwnerID
+		}
+
+		if ownerId != userId && ownerId != otherOwnerId {
+			log.Printf("Kitten owner is not one of the match owners : %+v", ownerId)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "kitten owner needs to be one of the match owners",
+			})
+		}
+
+		kitten := models.Cat{}
+		kitten.ID = uuid.New()
+		kitten.UserID = ownerId
+		kitten.NewCat = kittenRequest.NewCat
+		kitten.SireID = &sire.ID
+		kitten.DamID = &dam.ID
+		kitten.LitterID = &litter.ID
+		kitten.HasMatched = false
+		kitten.CreatedAt = litter.CreatedAt
+
+		if err := validate.Struct(kitten); err != nil {
+			log.Printf("Kitten doesn't pass validation : %+v", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": utils.ValidatorErrors(err),
+			})
+		}
+
+		kittens = append(kittens, kitten)
+		litter.Kittens = append(litter.Kittens, models.Kitten{
+			ID:       kitten.ID,
+			UserID:   kitten.UserID,
+			LitterID: litter.ID,
+			Name:     kitten.Name,
+			Sex:      kitten.Sex,
+		})
+	}
+
+	log.Printf("Litter data to add : %+v", litter)
+
+	if err := i.Repositories.CreateLitter(litter, kittens); err != nil {
+		log.Printf("Failed create new litter : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	event := &models.Event{
+		Type:        models.EventLitterRegistered,
+		RecipientID: otherOwnerId,
+		ActorID:     userId,
+		Payload:     litter,
+		CreatedAt:   litter.CreatedAt,
+	}
+
+	i.publishEvent(event)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "success",
+		"data":    litter,
+	})
+}
@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+const recoveryCodeCount = 10
+
+func (i *V1Repository) GetMFAStatus(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	status := models.MFAStatus{}
+
+	mfa, err := i.Repositories.GetUserMFA(principal.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to get the mfa enrollment : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err == nil && mfa.IsEnabled() {
+		status.Enabled = true
+		status.EnabledAt = mfa.EnabledAt
+
+		status.RemainingRecoveryCodes, err = i.Repositories.CountRemainingRecoveryCodes(principal.UserID)
+		if err != nil {
+			log.Printf("Failed to count the recovery codes : %+v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   fiber.ErrInternalServerError.Message,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    status,
+	})
+}
+
+func (i *V1Repository) EnrollTOTP(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("Failed to generate the totp secret : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	encrypted, err := utils.EncryptMFASecret(i.Config.MFA, secret)
+	if err != nil {
+		log.Printf("Failed to encrypt the totp secret : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	mfa := &models.UserMFA{
+		UserID:    user.ID,
+		Secret:    encrypted,
+		CreatedAt: time.Now(),
+	}
+
+	if err := i.Repositories.CreatePendingUserMFA(mfa); err != nil {
+		log.Printf("Failed to create the mfa enrollment : %+v", err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   fiber.ErrConflict.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "scan the code with an authenticator app, then verify it",
+		"data": models.TOTPEnrollment{
+			Secret:     secret,
+			OtpauthURL: utils.TOTPProvisioningURI(i.Config.MFA.Issuer, user.Email, secret),
+		},
+	})
+}
+
+func (i *V1Repository) ConfirmTOTP(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	codeRequest := &models.TOTPCodeRequest{}
+
+	if err := c.BodyParser(codeRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(codeRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	mfa, err := i.Repositories.GetUserMFA(principal.UserID)
+	if err != nil || mfa.IsEnabled() {
+		log.Printf("No pending mfa enrollment : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "no pending two-factor enrollment found",
+		})
+	}
+
+	secret, err := utils.DecryptMFASecret(i.Config.MFA, mfa.Secret)
+	if err != nil {
+		log.Printf("Failed to decrypt the totp secret : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	step, ok := utils.ValidateTOTP(secret, codeRequest.Code, time.Now())
+	if !ok {
+		log.Println("Invalid totp code for the enrollment")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "invalid two-factor code",
+		})
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Failed to generate the recovery codes : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.EnableUserMFA(principal.UserID, step, hashes); err != nil {
+		log.Printf("Failed to enable mfa : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &principal.UserID, models.AuditMFAEnabled, models.AuditTargetUser, principal.UserID.String(), nil)
+
+	return c.JSON(fiber.Map{
+		"message": "two-factor authentication enabled, store the recovery codes somewhere safe, they are only shown once",
+		"data": fiber.Map{
+			"recoveryCodes": codes,
+		},
+	})
+}
+
+func (i *V1Repository) DisableMFA(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	disableRequest := &models.DisableMFARequest{}
+
+	if err := c.BodyParser(disableRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(disableRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := utils.ComparePasswords(user.Password, disableRequest.Password); err != nil {
+		log.Printf("Failed to compare password : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "password is incorrect",
+		})
+	}
+
+	mfa, err := i.Repositories.GetUserMFA(user.ID)
+	if err != nil || !mfa.IsEnabled() {
+		log.Printf("Mfa is not enabled : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "two-factor authentication is not enabled",
+		})
+	}
+
+	if ferr := i.verifySecondFactor(mfa, disableRequest.Code, disableRequest.RecoveryCode); ferr != nil {
+		log.Printf("Failed to verify the second factor : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if err := i.Repositories.DeleteUserMFA(user.ID); err != nil {
+		log.Printf("Failed to disable mfa : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditMFADisabled, models.AuditTargetUser, user.ID.String(), nil)
+
+	return c.JSON(fiber.Map{
+		"message": "two-factor authentication disabled",
+	})
+}
+
+func (i *V1Repository) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	codeRequest := &models.TOTPCodeRequest{}
+
+	if err := c.BodyParser(codeRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(codeRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	mfa, err := i.Repositories.GetUserMFA(principal.UserID)
+	if err != nil || !mfa.IsEnabled() {
+		log.Printf("Mfa is not enabled : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "two-factor authentication is not enabled",
+		})
+	}
+
+	if ferr := i.verifySecondFactor(mfa, codeRequest.Code, ""); ferr != nil {
+		log.Printf("Failed to verify the second factor : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		log.Printf("Failed to generate the recovery codes : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.ReplaceRecoveryCodes(principal.UserID, hashes); err != nil {
+		log.Printf("Failed to store the recovery codes : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "new recovery codes generated, the previous ones no longer work",
+		"data": fiber.Map{
+			"recoveryCodes": codes,
+		},
+	})
+}
+
+// CompleteMFASignIn is the second step of the sign in when two-factor
+// authentication is enabled.
+func (i *V1Repository) CompleteMFASignIn(c *fiber.Ctx) error {
+	mfaRequest := &models.MFASignInRequest{}
+
+	if err := c.BodyParser(mfaRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(mfaRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	userId, err := utils.ParseMFAChallengeToken(i.Config.MFA, mfaRequest.MFAToken)
+	if err != nil {
+		log.Printf("Failed to parse the mfa token : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(userId)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": utils.ErrInvalidMFAToken.Error(),
+		})
+	}
+
+	// The codes are short, guesses count against the same budget as passwords.
+	if i.LoginGuard != nil {
+		wait, err := i.LoginGuard.Check(user.Email, c.IP())
+		if err != nil {
+			// Without the counters the throttling can't be enforced, so the
+			// sign in is refused rather than let through.
+			log.Printf("Failed to check the login attempts : %+v", err)
+			i.recordFailedLogin(c, user.Email, &user.ID, models.LoginFailureGuardUnavailable)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error":   fiber.ErrServiceUnavailable.Message,
+				"message": "sign in is temporarily unavailable, please try again later",
+			})
+		}
+
+		if wait > 0 {
+			i.recordFailedLogin(c, user.Email, &user.ID, models.LoginFailureThrottled)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   fiber.ErrTooManyRequests.Message,
+				"message": "too many failed sign in attempts, please try again later",
+			})
+		}
+	}
+
+	mfa, err := i.Repositories.GetUserMFA(user.ID)
+	if err != nil || !mfa.IsEnabled() {
+		log.Printf("Mfa is not enabled : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": utils.ErrInvalidMFAToken.Error(),
+		})
+	}
+
+	if ferr := i.verifySecondFactor(mfa, mfaRequest.Code, mfaRequest.RecoveryCode); ferr != nil {
+		log.Printf("Failed to verify the second factor : %+v", ferr)
+		if ferr.Code == fiber.StatusUnauthorized {
+			i.failLogin(c, user.Email, &user.ID, models.LoginFailureWrongMFACode)
+		}
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if i.LoginGuard != nil {
+		if err := i.LoginGuard.Succeed(user.Email); err != nil {
+			log.Printf("Failed to reset the login attempts : %+v", err)
+		}
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditUserLogin, models.AuditTargetUser, user.ID.String(), map[string]interface{}{
+		"method": "mfa",
+	})
+
+	token, err := i.startSession(c, user.ID)
+	if err != nil {
+		log.Printf("Failed to start the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	responseData := models.AuthResponse{
+		Email:         user.Email,
+		Name:          user.Name,
+		AccessToken:   token.Access,
+		RefreshToken:  token.Refresh,
+		EmailVerified: user.IsEmailVerified(),
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User logged successfully",
+		"data":    responseData,
+	})
+}
+
+// verifySecondFactor accepts a TOTP code, or a recovery code when no TOTP code
+// is given. Both can be used only once.
+func (i *V1Repository) verifySecondFactor(mfa models.UserMFA, code string, recoveryCode string) *fiber.Error {
+	if code == "" {
+		if err := i.Repositories.UseRecoveryCode(mfa.UserID, utils.HashRecoveryCode(recoveryCode)); err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "invalid two-factor code")
+		}
+		return nil
+	}
+
+	secret, err := utils.DecryptMFASecret(i.Config.MFA, mfa.Secret)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid two-factor code")
+	}
+
+	if err := i.Repositories.UseTOTPStep(mfa.UserID, step); err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, "two-factor code already used, wait for the next one")
+	}
+
+	return nil
+}
+
+// mfaChallenge returns the MFA token when the user enabled two-factor
+// authentication, nil otherwise.
+func (i *V1Repository) mfaChallenge(userId uuid.UUID) (*models.MFAChallengeResponse, error) {
+	mfa, err := i.Repositories.GetUserMFA(userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if !mfa.IsEnabled() {
+		return nil, nil
+	}
+
+	return &models.MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    utils.GenerateMFAChallengeToken(i.Config.MFA, userId, time.Now().Add(utils.MFAChallengeTTL)),
+	}, nil
+}
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// NotificationRecorder stores every event in the inbox of its recipient,
+// unless the recipient muted that type of notification. The events wait in a
+// buffer and are written by Run, so the publisher never waits on the database.
+type NotificationRecorder struct {
+	repo   *repositories.DatabaseRepositories
+	events chan models.Event
+}
+
+func NewNotificationRecorder(repo *repositories.DatabaseRepositories, size int) *NotificationRecorder {
+	return &NotificationRecorder{
+		repo:   repo,
+		events: make(chan models.Event, size),
+	}
+}
+
+// Record is the subscriber of the event bus, it never blocks.
+func (r *NotificationRecorder) Record(e models.Event) {
+	if e.RecipientID == uuid.Nil {
+		return
+	}
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+
+	select {
+	case r.events <- e:
+	default:
+		log.Printf("Dropped %s notification for %s, the recorder is full", e.Type, e.RecipientID)
+	}
+}
+
+// Run writes the buffered notifications until the stop channel is closed.
+func (r *NotificationRecorder) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case e := <-r.events:
+			r.store(e)
+		}
+	}
+}
+
+func (r *NotificationRecorder) store(e models.Event) {
+	enabled, err := r.repo.IsNotificationEnabled(e.RecipientID, e.Type)
+	if err != nil {
+		log.Printf("Failed to get notification preference : %+v", err)
+		return
+	}
+
+	if !enabled {
+		return
+	}
+
+	payload, err := json.Marshal(e.Payload)
+	if err != nil {
+		log.Printf("Failed to marshal notification payload : %+v", err)
+		return
+	}
+
+	notification := &models.Notification{
+		ID:        uuid.New(),
+		UserID:    e.RecipientID,
+		Type:      e.Type,
+		Payload:   payload,
+		CreatedAt: e.CreatedAt,
+	}
+
+	if e.ActorID != uuid.Nil {
+		notification.ActorID = &e.ActorID
+	}
+
+	if err := r.repo.CreateNotification(notification); err != nil {
+		log.Printf("Failed create new notification : %+v", err)
+	}
+}
+
+func (i *V1Repository) GetNotifications(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	limit := defaultNotificationPageSize
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > maxNotificationPageSize {
+			log.Printf("Invalid limit query : %+v", limitStr)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "limit must be a number between 1 and " + strconv.Itoa(maxNotificationPageSize),
+			})
+		}
+	}
+
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			log.Printf("Invalid offset query : %+v", offsetStr)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "offset must be a positive number",
+			})
+		}
+	}
+
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, err := i.Repositories.GetNotifications(principal.UserID, unreadOnly, limit, offset)
+	if err != nil {
+		log.Printf("Failed to get notifications : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	unreadCount, err := i.Repositories.CountUnreadNotifications(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to count unread notifications : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    notifications,
+		"meta": fiber.Map{
+			"unreadCount": unreadCount,
+			"limit":       limit,
+			"offset":      offset,
+		},
+	})
+}
+
+func (i *V1Repository) MarkNotificationRead(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	notificationId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.MarkNotificationRead(notificationId, principal.UserID); err != nil {
+		log.Printf("Failed to mark notification as read : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"id":      notificationId,
+		"message": "success",
+	})
+}
+
+func (i *V1Repository) MarkAllNotificationsRead(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	marked, err := i.Repositories.MarkAllNotificationsRead(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to mark all notifications as read : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data": fiber.Map{
+			"marked": marked,
+		},
+	})
+}
+
+func (i *V1Repository) GetNotificationPreferences(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return i.sendNotificationPreferences(c, principal.UserID)
+}
+
+func (i *V1Repository) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	preferencesRequest := &models.NotificationPreferencesRequest{}
+
+	if err := c.BodyParser(preferencesRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(preferencesRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	for notificationType := range preferencesRequest.Preferences {
+		if !isNotificationType(notificationType) {
+			log.Printf("Unknown notification type : %+v", notificationType)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "unknown notification type " + notificationType,
+			})
+		}
+	}
+
+	for notificationType, enabled := range preferencesRequest.Preferences {
+		preference := models.NotificationPreference{Type: notificationType, Enabled: enabled}
+		if err := i.Repositories.UpsertNotificationPreference(principal.UserID, preference); err != nil {
+			log.Printf("Failed to update notification preference : %+v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   fiber.ErrInternalServerError.Message,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	return i.sendNotificationPreferences(c, principal.UserID)
+}
+
+func (i *V1Repository) sendNotificationPreferences(c *fiber.Ctx, userId uuid.UUID) error {
+	stored, err := i.Repositories.GetNotificationPreferences(userId)
+	if err != nil {
+		log.Printf("Failed to get notification preferences : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	preferences := map[string]bool{}
+	for _, notificationType := range models.NotificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, notificationType := range models.EmailNotificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    preferences,
+	})
+}
+
+func isNotificationType(notificationType string) bool {
+	for _, known := range models.NotificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+
+	for _, known := range models.EmailNotificationTypes {
+		if known == notificationType {
+			return true
+		}
+	}
+
+	return false
+}
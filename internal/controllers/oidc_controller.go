@@ -0,0 +1,490 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/oidc"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// oidcStateTTL is how long the user has to sign in at the provider.
+const oidcStateTTL = 10 * time.Minute
+
+// oidcUserStore is what finding or linking the user of an identity needs from
+// the repositories.
+type oidcUserStore interface {
+	GetUserIdentity(provider string, subject string) (models.UserIdentity, error)
+	TouchUserIdentity(id uuid.UUID, email string) error
+	GetUserByID(id uuid.UUID) (models.User, error)
+	GetUserByEmail(email string) (models.User, error)
+	CreateUserIdentity(i *models.UserIdentity) error
+	CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error
+}
+
+func (i *V1Repository) GetOIDCProviders(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    i.OIDC.Names(),
+	})
+}
+
+// StartOIDCSignIn sends the browser to the provider.
+func (i *V1Repository) StartOIDCSignIn(c *fiber.Ctx) error {
+	provider, ok := i.OIDC.Get(c.Params("provider"))
+	if !ok {
+		log.Printf("Unknown oidc provider : %+v", c.Params("provider"))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "identity provider not found",
+		})
+	}
+
+	authorizationURL, err := i.oidcAuthorizationURL(c, provider, nil)
+	if err != nil {
+		log.Printf("Failed to start the oidc sign in : %+v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   fiber.ErrBadGateway.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Redirect(authorizationURL, fiber.StatusFound)
+}
+
+// LinkOIDCIdentity starts the same flow for the signed in user, the identity
+// is linked to the account when the provider calls back. The URL is returned
+// instead of a redirect since the request carries the access token.
+func (i *V1Repository) LinkOIDCIdentity(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	provider, ok := i.OIDC.Get(c.Params("provider"))
+	if !ok {
+		log.Printf("Unknown oidc provider : %+v", c.Params("provider"))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "identity provider not found",
+		})
+	}
+
+	authorizationURL, err := i.oidcAuthorizationURL(c, provider, &principal.UserID)
+	if err != nil {
+		log.Printf("Failed to start the oidc link : %+v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   fiber.ErrBadGateway.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "open the authorization url to link the account",
+		"data":    models.OIDCAuthorizationResponse{AuthorizationURL: authorizationURL},
+	})
+}
+
+func (i *V1Repository) OIDCCallback(c *fiber.Ctx) error {
+	provider, ok := i.OIDC.Get(c.Params("provider"))
+	if !ok {
+		log.Printf("Unknown oidc provider : %+v", c.Params("provider"))
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "identity provider not found",
+		})
+	}
+
+	if providerError := c.Query("error"); providerError != "" {
+		log.Printf("Oidc provider returned an error : %+v %+v", providerError, c.Query("error_description"))
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": "sign in was cancelled or denied by the identity provider",
+		})
+	}
+
+	code := c.Query("code")
+	state := c.Query("state")
+	if code == "" || state == "" {
+		log.Println("Missing code or state in the oidc callback")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "code and state are required",
+		})
+	}
+
+	loginState, err := i.Repositories.ConsumeOIDCLoginState(oidc.HashState(state), provider.Name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Println("Unknown or expired oidc state")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "the sign in request expired, please try again",
+			})
+		}
+
+		log.Printf("Failed to get the oidc state : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	tokens, err := provider.Exchange(c.Context(), code, loginState.CodeVerifier)
+	if err != nil {
+		log.Printf("Failed to exchange the authorization code : %+v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error":   fiber.ErrBadGateway.Message,
+			"message": "the identity provider rejected the authorization code",
+		})
+	}
+
+	claims, err := provider.VerifyIDToken(c.Context(), tokens.IDToken, loginState.Nonce)
+	if err != nil {
+		log.Printf("Failed to verify the id token : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if loginState.UserID != nil {
+		identity, ferr := linkOIDCIdentity(i.Repositories, *loginState.UserID, provider.Name, claims)
+		if ferr != nil {
+			log.Printf("Failed to link the identity : %+v", ferr)
+			return c.Status(ferr.Code).JSON(fiber.Map{
+				"error":   fiber.NewError(ferr.Code).Message,
+				"message": ferr.Message,
+			})
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"message": "account linked",
+			"data":    identity,
+		})
+	}
+
+	user, created, ferr := resolveOIDCUser(i.Repositories, i.Config.Password, provider.Name, claims)
+	if ferr != nil {
+		log.Printf("Failed to sign in with the identity : %+v", ferr)
+		return c.Status(ferr.Code).JSON(fiber.Map{
+			"error":   fiber.NewError(ferr.Code).Message,
+			"message": ferr.Message,
+		})
+	}
+
+	if created && !user.IsEmailVerified() {
+		if err := i.sendVerificationEmail(user); err != nil {
+			log.Printf("Failed to send the verification email : %+v", err)
+		}
+	}
+
+	challenge, err := i.mfaChallenge(user.ID)
+	if err != nil {
+		log.Printf("Failed to get the mfa enrollment : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if challenge != nil {
+		return c.JSON(fiber.Map{
+			"message": "two-factor authentication required",
+			"data":    challenge,
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditUserLogin, models.AuditTargetUser, user.ID.String(), map[string]interface{}{
+		"method":   "oidc",
+		"provider": provider.Name,
+	})
+
+	token, err := i.startSession(c, user.ID)
+	if err != nil {
+		log.Printf("Failed to start the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	responseData := models.AuthResponse{
+		Email:         user.Email,
+		Name:          user.Name,
+		AccessToken:   token.Access,
+		RefreshToken:  token.Refresh,
+		EmailVerified: user.IsEmailVerified(),
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "User logged successfully",
+		"data":    responseData,
+	})
+}
+
+func (i *V1Repository) GetUserIdentities(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	identities, err := i.Repositories.GetUserIdentitiesByUserId(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get the identities : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    identities,
+	})
+}
+
+func (i *V1Repository) UnlinkOIDCIdentity(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	identityId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	deleted, err := i.Repositories.DeleteUserIdentity(identityId, principal.UserID)
+	if err != nil {
+		log.Printf("Failed to unlink the identity : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if !deleted {
+		log.Println("Identity not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "identity with this ID not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "identity unlinked",
+	})
+}
+
+// oidcAuthorizationURL stores the state, nonce and PKCE verifier of a new
+// authorization request and returns the URL of the provider.
+func (i *V1Repository) oidcAuthorizationURL(c *fiber.Ctx, provider *oidc.Provider, userId *uuid.UUID) (string, error) {
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	verifier, err := oidc.RandomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	authorizationURL, err := provider.AuthCodeURL(c.Context(), state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	loginState := &models.OIDCLoginState{
+		StateHash:    oidc.HashState(state),
+		Provider:     provider.Name,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserID:       userId,
+		ExpiresAt:    now.Add(oidcStateTTL),
+		CreatedAt:    now,
+	}
+
+	if err := i.Repositories.CreateOIDCLoginState(loginState); err != nil {
+		return "", err
+	}
+
+	return authorizationURL, nil
+}
+
+// resolveOIDCUser finds the user of the identity. An unknown identity is
+// linked to the account with the same email when both the provider and the
+// account verified that email, otherwise a new account is created and created
+// is true.
+func resolveOIDCUser(store oidcUserStore, cfg config.Password, provider string, claims *oidc.Claims) (models.User, bool, *fiber.Error) {
+	identity, err := store.GetUserIdentity(provider, claims.Subject)
+	if err == nil {
+		if err := store.TouchUserIdentity(identity.ID, claims.Email); err != nil {
+			log.Printf("Failed to update the identity : %+v", err)
+		}
+
+		user, err := store.GetUserByID(identity.UserID)
+		if err != nil {
+			return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		return user, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.User{}, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if _, err := mail.ParseAddress(claims.Email); err != nil {
+		return models.User{}, false, fiber.NewError(fiber.StatusBadRequest, "the identity provider didn't share a valid email address")
+	}
+
+	now := time.Now()
+
+	newIdentity := &models.UserIdentity{
+		ID:          uuid.New(),
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+		CreatedAt:   now,
+	}
+
+	user, err := store.GetUserByEmail(claims.Email)
+	if err == nil {
+		// Linking on an unverified email would let anyone who can register
+		// that address at the provider take over the account. An account that
+		// never verified its email may have been registered by someone else
+		// ahead of the owner, who would then sign in to an account whose
+		// password is known to that person.
+		if !claims.IsEmailVerified() || !user.IsEmailVerified() {
+			return user, false, fiber.NewError(fiber.StatusConflict, "an account with this email already exists, sign in with your password and link the provider from your profile")
+		}
+
+		newIdentity.UserID = user.ID
+		if err := store.CreateUserIdentity(newIdentity); err != nil {
+			return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		return user, false, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Accounts created from a provider get a random password, the owner can
+	// set one with the password reset flow.
+	randomPassword, err := oidc.RandomString(32)
+	if err != nil {
+		return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	passwordHash, err := utils.GeneratePassword(cfg, randomPassword)
+	if err != nil {
+		return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	user = models.User{
+		ID:         uuid.New(),
+		Email:      claims.Email,
+		Name:       oidcDisplayName(claims),
+		Password:   passwordHash,
+		UserStatus: 1,
+		UserRole:   models.UserRoleUser,
+		CreatedAt:  now,
+	}
+
+	if claims.IsEmailVerified() {
+		user.EmailVerifiedAt = &now
+	}
+
+	newIdentity.UserID = user.ID
+
+	if err := store.CreateUserWithIdentity(&user, newIdentity); err != nil {
+		return user, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return user, true, nil
+}
+
+func linkOIDCIdentity(store oidcUserStore, userId uuid.UUID, provider string, claims *oidc.Claims) (*models.UserIdentity, *fiber.Error) {
+	identity, err := store.GetUserIdentity(provider, claims.Subject)
+	if err == nil {
+		if identity.UserID != userId {
+			return nil, fiber.NewError(fiber.StatusConflict, "this identity is already linked to another account")
+		}
+
+		return &identity, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	now := time.Now()
+
+	newIdentity := &models.UserIdentity{
+		ID:        uuid.New(),
+		UserID:    userId,
+		Provider:  provider,
+		Subject:   claims.Subject,
+		Email:     claims.Email,
+		CreatedAt: now,
+	}
+
+	if err := store.CreateUserIdentity(newIdentity); err != nil {
+		if strings.Contains(err.Error(), "user_identities_user_id_provider_key") {
+			return nil, fiber.NewError(fiber.StatusConflict, "another account of this provider is already linked, unlink it first")
+		}
+
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return newIdentity, nil
+}
+
+// oidcDisplayName fits the name of the provider to the rules of a user name,
+// falling back on the start of the email.
+func oidcDisplayName(claims *oidc.Claims) string {
+	for _, name := range []string{strings.TrimSpace(claims.Name), strings.Split(claims.Email, "@")[0]} {
+		length := utf8.RuneCountInString(name)
+		if length >= 5 && length <= 50 {
+			return name
+		}
+		if length > 50 {
+			return string([]rune(name)[:50])
+		}
+	}
+
+	return "Cat Social user"
+}
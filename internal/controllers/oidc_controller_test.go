@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/oidc"
+)
+
+// fakeOIDCStore keeps the users and identities in memory.
+type fakeOIDCStore struct {
+	users      map[uuid.UUID]models.User
+	identities []models.UserIdentity
+	touched    []uuid.UUID
+}
+
+func newFakeOIDCStore(users ...models.User) *fakeOIDCStore {
+	s := &fakeOIDCStore{users: map[uuid.UUID]models.User{}}
+	for _, u := range users {
+		s.users[u.ID] = u
+	}
+
+	return s
+}
+
+func (s *fakeOIDCStore) GetUserIdentity(provider string, subject string) (models.UserIdentity, error) {
+	for _, identity := range s.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+
+	return models.UserIdentity{}, sql.ErrNoRows
+}
+
+func (s *fakeOIDCStore) TouchUserIdentity(id uuid.UUID, email string) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+func (s *fakeOIDCStore) GetUserByID(id uuid.UUID) (models.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return models.User{}, sql.ErrNoRows
+	}
+
+	return user, nil
+}
+
+func (s *fakeOIDCStore) GetUserByEmail(email string) (models.User, error) {
+	for _, user := range s.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return models.User{}, sql.ErrNoRows
+}
+
+func (s *fakeOIDCStore) CreateUserIdentity(i *models.UserIdentity) error {
+	for _, identity := range s.identities {
+		if identity.UserID == i.UserID && identity.Provider == i.Provider {
+			return errors.New(`duplicate key value violates unique constraint "user_identities_user_id_provider_key"`)
+		}
+	}
+
+	s.identities = append(s.identities, *i)
+	return nil
+}
+
+func (s *fakeOIDCStore) CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error {
+	s.users[u.ID] = *u
+	s.identities = append(s.identities, *i)
+	return nil
+}
+
+// The cheapest hasher keeps the account creation fast.
+var testPasswordConfig = config.Password{Hasher: "bcrypt", BcryptCost: 4}
+
+func oidcClaims(subject string, email string, verified interface{}) *oidc.Claims {
+	claims := &oidc.Claims{Email: email, EmailVerified: verified, Name: "Jane Doe"}
+	claims.Subject = subject
+
+	return claims
+}
+
+func existingUser(email string, verified bool) models.User {
+	user := models.User{ID: uuid.New(), Email: email, Name: "Jane Doe", UserStatus: 1, CreatedAt: time.Now()}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	return user
+}
+
+func TestResolveOIDCUserLinksVerifiedEmailToExistingAccount(t *testing.T) {
+	user := existingUser("jane@example.com", true)
+	store := newFakeOIDCStore(user)
+
+	got, created, ferr := resolveOIDCUser(store, testPasswordConfig, "google", oidcClaims("google-1", "jane@example.com", true))
+	if ferr != nil {
+		t.Fatalf("resolveOIDCUser : %v", ferr)
+	}
+
+	if got.ID != user.ID || created {
+		t.Fatalf("resolved %v (created %v), want the existing account %v", got.ID, created, user.ID)
+	}
+	if len(store.identities) != 1 || store.identities[0].UserID != user.ID || store.identities[0].Subject != "google-1" {
+		t.Fatalf("identities = %+v, want google-1 linked to the account", store.identities)
+	}
+
+	// The next sign in finds the account through the linked identity.
+	got, created, ferr = resolveOIDCUser(store, testPasswordConfig, "google", oidcClaims("google-1", "jane@example.com", true))
+	if ferr != nil || got.ID != user.ID || created {
+		t.Fatalf("second sign in = %v, %v, %v, want the linked account", got.ID, created, ferr)
+	}
+	if len(store.touched) != 1 || len(store.identities) != 1 {
+		t.Errorf("touched %d and stored %d identities, want one of each", len(store.touched), len(store.identities))
+	}
+}
+
+func TestResolveOIDCUserRefusesUnverifiedEmailOfExistingAccount(t *testing.T) {
+	store := newFakeOIDCStore(existingUser("jane@example.com", true))
+
+	for _, verified := range []interface{}{false, "false", nil} {
+		_, _, ferr := resolveOIDCUser(store, testPasswordConfig, "google", oidcClaims("google-1", "jane@example.com", verified))
+		if ferr == nil || ferr.Code != fiber.StatusConflict {
+			t.Errorf("email_verified %#v : got %v, want 409", verified, ferr)
+		}
+	}
+
+	if len(store.identities) != 0 {
+		t.Errorf("identities = %+v, want nothing linked", store.identities)
+	}
+}
+
+func TestResolveOIDCUserRefusesUnverifiedExistingAccount(t *testing.T) {
+	store := newFakeOIDCStore(existingUser("jane@example.com", false))
+
+	_, created, ferr := resolveOIDCUser(store, testPasswordConfig, "google", oidcClaims("google-1", "jane@example.com", true))
+	if ferr == nil || ferr.Code != fiber.StatusConflict || created {
+		t.Fatalf("got %v (created %v), want 409", ferr, created)
+	}
+
+	if len(store.identities) != 0 {
+		t.Errorf("identities = %+v, want nothing linked to the unverified account", store.identities)
+	}
+}
+
+func TestResolveOIDCUserCreatesAccount(t *testing.T) {
+	for _, verified := range []bool{true, false} {
+		store := newFakeOIDCStore()
+
+		user, created, ferr := resolveOIDCUser(store, testPasswordConfig, "google", oidcClaims("google-1", "jane@example.com", verified))
+		if ferr != nil {
+			t.Fatalf("resolveOIDCUser : %v", ferr)
+		}
+
+		if !created || store.users[user.ID].Email != "jane@example.com" {
+			t.Fatalf("created %v with %+v, want a new account", created, store.users)
+		}
+		if user.IsEmailVerified() != verified {
+			t.Errorf("email verified = %v, want %v like the provider", user.IsEmailVerified(), verified)
+		}
+		if user.Password == "" || user.UserRole != models.UserRoleUser {
+			t.Errorf("new account = %+v, want a random password and the user role", user)
+		}
+		if len(store.identities) != 1 || store.identities[0].UserID != user.ID {
+			t.Errorf("identities = %+v, want the identity of the new account", store.identities)
+		}
+	}
+}
+
+func TestResolveOIDCUserRequiresEmail(t *testing.T) {
+	_, _, ferr := resolveOIDCUser(newFakeOIDCStore(), testPasswordConfig, "google", oidcClaims("google-1", "", true))
+	if ferr == nil || ferr.Code != fiber.StatusBadRequest {
+		t.Fatalf("got %v, want 400", ferr)
+	}
+}
+
+func TestLinkOIDCIdentity(t *testing.T) {
+	jane := existingUser("jane@example.com", true)
+	john := existingUser("john@example.com", true)
+	store := newFakeOIDCStore(jane, john)
+
+	identity, ferr := linkOIDCIdentity(store, jane.ID, "google", oidcClaims("google-1", "jane@gmail.com", false))
+	if ferr != nil {
+		t.Fatalf("linkOIDCIdentity : %v", ferr)
+	}
+	if identity.UserID != jane.ID || identity.Email != "jane@gmail.com" {
+		t.Fatalf("identity = %+v, want it linked to jane", identity)
+	}
+
+	// Linking the same identity again is a no-op.
+	if again, ferr := linkOIDCIdentity(store, jane.ID, "google", oidcClaims("google-1", "jane@gmail.com", false)); ferr != nil || again.ID != identity.ID {
+		t.Errorf("relinking = %+v, %v, want the existing identity", again, ferr)
+	}
+
+	if _, ferr := linkOIDCIdentity(store, john.ID, "google", oidcClaims("google-1", "jane@gmail.com", false)); ferr == nil || ferr.Code != fiber.StatusConflict {
+		t.Errorf("linking the identity of jane to john = %v, want 409", ferr)
+	}
+
+	if _, ferr := linkOIDCIdentity(store, jane.ID, "google", oidcClaims("google-2", "other@gmail.com", false)); ferr == nil || ferr.Code != fiber.StatusConflict {
+		t.Errorf("linking a second google account = %v, want 409", ferr)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/mailer"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) ForgotPassword(c *fiber.Ctx) error {
+	forgotRequest := &models.ForgotPasswordRequest{}
+
+	if err := c.BodyParser(forgotRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(forgotRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	// The lookup runs in the background and failures are only logged, so
+	// neither the response nor its timing tells whether the email exists.
+	email := forgotRequest.Email
+	go func() {
+		user, err := i.Repositories.GetUserByEmail(email)
+		if err != nil {
+			if !errors.Is(err, sql.ErrNoRows) {
+				log.Printf("Failed to get user data : %+v", err)
+			}
+			return
+		}
+
+		if err := i.sendPasswordResetEmail(user); err != nil {
+			log.Printf("Failed to send the password reset email : %+v", err)
+		}
+	}()
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "if this email is registered, a password reset link has been sent",
+	})
+}
+
+func (i *V1Repository) ResetPassword(c *fiber.Ctx) error {
+	resetRequest := &models.ResetPasswordRequest{}
+
+	if err := c.BodyParser(resetRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(resetRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	if err := utils.ValidatePassword(i.Config.Password, resetRequest.Password); err != nil {
+		log.Printf("Password doesn't pass the policy : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	passwordHash, err := utils.GeneratePassword(i.Config.Password, resetRequest.Password)
+	if err != nil {
+		log.Printf("Failed to hash the password : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	userId, err := i.Repositories.ResetUserPassword(utils.HashResetToken(resetRequest.Token), passwordHash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			log.Println("Password reset token is invalid, used or expired")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": "invalid or expired password reset token",
+			})
+		}
+
+		log.Printf("Failed to reset the password : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("Password of user %+v was reset", userId)
+
+	i.recordAudit(c, &userId, models.AuditPasswordReset, models.AuditTargetUser, userId.String(), nil)
+
+	return c.JSON(fiber.Map{
+		"message": "password reset successfully, please sign in again",
+	})
+}
+
+func (i *V1Repository) sendPasswordResetEmail(user models.User) error {
+	if i.Mailer == nil {
+		return errors.New("mailer is not configured")
+	}
+
+	token, tokenHash, err := utils.GenerateResetToken()
+	if err != nil {
+		return err
+	}
+
+	ttl := i.Config.PasswordReset.TTL
+	now := time.Now()
+
+	resetToken := &models.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+
+	if err := i.Repositories.CreatePasswordResetToken(resetToken); err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(mailer.TemplatePasswordReset, user.Email, mailer.TemplateData{
+		RecipientName: user.Name,
+		ActionURL:     i.BaseURL + "/reset-password?token=" + url.QueryEscape(token),
+		ExpiresIn:     strconv.Itoa(int(ttl.Minutes())) + " minutes",
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.Mailer.Enqueue(msg)
+}
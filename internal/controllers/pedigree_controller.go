@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+const (
+	defaultPedigreeGenerations = 3
+	maxPedigreeGenerations     = 10
+	// Generations walked when computing the inbreeding warning of a match.
+	matchInbreedingGenerations = 6
+	// Generations walked when computing the inbreeding coefficient of a cat,
+	// whatever the depth of the pedigree shown.
+	pedigreeInbreedingGenerations = maxPedigreeGenerations
+)
+
+func (i *V1Repository) GetCatPedigree(c *fiber.Ctx) error {
+	catId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	generations := defaultPedigreeGenerations
+	if generationsStr := c.Query("generations"); generationsStr != "" {
+		generations, err = strconv.Atoi(generationsStr)
+		if err != nil || generations < 1 || generations > maxPedigreeGenerations {
+			log.Printf("Invalid generations query : %+v", generationsStr)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": fmt.Sprintf("generations must be a number between 1 and %d", maxPedigreeGenerations),
+			})
+		}
+	}
+
+	cat, err := i.Repositories.GetCatById(catId)
+	if err != nil {
+		log.Printf("Failed to get cat data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if len(cat) == 0 {
+		log.Println("Cat not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "cat with this ID not found",
+		})
+	}
+
+	ancestors, err := i.Repositories.GetCatAncestors(catId, pedigreeInbreedingGenerations)
+	if err != nil {
+		log.Printf("Failed to get cat ancestors : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	pedigree := models.PedigreeResponse{
+		Generations: generations,
+		Pedigree:    utils.BuildPedigree(catId, ancestorsWithin(ancestors, generations)),
+	}
+
+	if cat[0].SireID != nil && cat[0].DamID != nil {
+		pedigree.InbreedingCoefficient = utils.InbreedingCoefficient(*cat[0].SireID, *cat[0].DamID, ancestors)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    pedigree,
+	})
+}
+
+// ancestorsWithin keeps the ancestors at most the given generations away, each
+// ancestor carries the generation of its closest path to the cat.
+func ancestorsWithin(ancestors []models.CatAncestor, generations int) []models.CatAncestor {
+	within := []models.CatAncestor{}
+	for _, ancestor := range ancestors {
+		if ancestor.Generation <= generations {
+			within = append(within, ancestor)
+		}
+	}
+
+	return within
+}
+
+// checkCatParents makes sure the sire and dam exist, have the right sex and are
+// not descendants of the cat itself. The returned error carries the HTTP status.
+func (i *V1Repository) checkCatParents(catId uuid.UUID, sireId *uuid.UUID, damId *uuid.UUID) *fiber.Error {
+	parents := []struct {
+		role string
+		id   *uuid.UUID
+		sex  string
+	}{
+		{role: "sire", id: sireId, sex: "male"},
+		{role: "dam", id: damId, sex: "female"},
+	}
+
+	for _, parent := range parents {
+		if parent.id == nil {
+			continue
+		}
+
+		if *parent.id == catId {
+			return fiber.NewError(fiber.StatusBadRequest, "cat cant be its own "+parent.role)
+		}
+
+		parentCat, err := i.Repositories.GetCatById(*parent.id)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		if len(parentCat) == 0 {
+			return fiber.NewError(fiber.StatusNotFound, parent.role+" not found, please check your request")
+		}
+
+		if parentCat[0].Sex != parent.sex {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("%s needs to be a %s cat", parent.role, parent.sex))
+		}
+
+		isDescendant, err := i.Repositories.IsCatAncestor(catId, *parent.id)
+		if err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		if isDescendant {
+			return fiber.NewError(fiber.StatusBadRequest, parent.role+" cant be a descendant of this cat")
+		}
+	}
+
+	return nil
+}
+
+func (i *V1Repository) matchInbreedingCoefficient(firstCatId uuid.UUID, secondCatId uuid.UUID) (float64, error) {
+	ancestors, err := i.Repositories.GetCatsAncestors([]uuid.UUID{firstCatId, secondCatId}, matchInbreedingGenerations)
+	if err != nil {
+		return 0, err
+	}
+
+	return pairInbreedingCoefficient(firstCatId, secondCatId, ancestors), nil
+}
+
+// pairInbreedingCoefficient takes the ancestors of GetCatsAncestors, so the
+// pedigrees of a whole list of matches are read at once.
+func pairInbreedingCoefficient(firstCatId uuid.UUID, secondCatId uuid.UUID, ancestors map[uuid.UUID][]models.CatAncestor) float64 {
+	pair := append([]models.CatAncestor{}, ancestors[firstCatId]...)
+	pair = append(pair, ancestors[secondCatId]...)
+
+	return utils.InbreedingCoefficient(firstCatId, secondCatId, pair)
+}
+
+func inbreedingWarning(coefficient float64) string {
+	if coefficient < utils.InbreedingWarningThreshold {
+		return ""
+	}
+
+	return fmt.Sprintf("both cats are closely related, a litter would have an inbreeding coefficient of %.2f%%", coefficient*100)
+}
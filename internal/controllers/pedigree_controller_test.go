@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func TestAncestorsWithinKeepsTheCoefficient(t *testing.T) {
+	kitten, sire, dam, grandsire, granddam := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	// The kitten of two full siblings, as GetCatAncestors returns it.
+	ancestors := []models.CatAncestor{
+		{ID: kitten, SireID: &sire, DamID: &dam, Generation: 0},
+		{ID: sire, SireID: &grandsire, DamID: &granddam, Generation: 1},
+		{ID: dam, SireID: &grandsire, DamID: &granddam, Generation: 1},
+		{ID: grandsire, Generation: 2},
+		{ID: granddam, Generation: 2},
+	}
+
+	shown := ancestorsWithin(ancestors, 1)
+	if len(shown) != 3 {
+		t.Fatalf("ancestors within one generation = %d, want the kitten and its parents", len(shown))
+	}
+
+	root := utils.BuildPedigree(kitten, shown)
+	if root.Sire == nil || root.Sire.Sire != nil {
+		t.Errorf("pedigree = %+v, want it to stop at the parents", root)
+	}
+
+	// The coefficient is computed from every ancestor, not the ones shown.
+	if got := utils.InbreedingCoefficient(sire, dam, ancestors); got != 0.25 {
+		t.Errorf("InbreedingCoefficient = %v, want 0.25", got)
+	}
+}
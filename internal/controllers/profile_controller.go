@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) GetProfile(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    user.Profile(),
+	})
+}
+
+func (i *V1Repository) UpdateProfile(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	profileRequest := &models.UpdateProfileRequest{}
+
+	if err := c.BodyParser(profileRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(profileRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	if profileRequest.Name == nil && profileRequest.Bio == nil && profileRequest.AvatarURL == nil {
+		log.Println("Nothing to update in the profile")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "at least one of name, bio or avatarUrl is required",
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if profileRequest.Name != nil {
+		user.Name = *profileRequest.Name
+	}
+
+	if profileRequest.Bio != nil {
+		user.Bio = *profileRequest.Bio
+	}
+
+	if profileRequest.AvatarURL != nil {
+		user.AvatarURL = *profileRequest.AvatarURL
+	}
+
+	if err := i.Repositories.UpdateUserProfile(&user); err != nil {
+		log.Printf("Failed to update the profile : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    user.Profile(),
+	})
+}
+
+func (i *V1Repository) ChangePassword(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	passwordRequest := &models.ChangePasswordRequest{}
+
+	if err := c.BodyParser(passwordRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(passwordRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := utils.ComparePasswords(user.Password, passwordRequest.CurrentPassword); err != nil {
+		log.Printf("Failed to compare password : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "current password is incorrect",
+		})
+	}
+
+	if err := utils.ValidatePassword(i.Config.Password, passwordRequest.NewPassword, user.Email, user.Name); err != nil {
+		log.Printf("Password doesn't pass the policy : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	passwordHash, err := utils.GeneratePassword(i.Config.Password, passwordRequest.NewPassword)
+	if err != nil {
+		log.Printf("Failed to hash the password : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.UpdateUserPassword(user.ID, passwordHash); err != nil {
+		log.Printf("Failed to update the password : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditPasswordChanged, models.AuditTargetUser, user.ID.String(), nil)
+
+	// Other devices are signed out, this one gets fresh tokens.
+	tokens, err := i.startSession(c, user.ID)
+	if err != nil {
+		log.Printf("Failed to start the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "password changed successfully",
+		"tokens": fiber.Map{
+			"access":  tokens.Access,
+			"refresh": tokens.Refresh,
+		},
+	})
+}
+
+func (i *V1Repository) ChangeEmail(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	emailRequest := &models.ChangeEmailRequest{}
+
+	if err := c.BodyParser(emailRequest); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(emailRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := utils.ComparePasswords(user.Password, emailRequest.Password); err != nil {
+		log.Printf("Failed to compare password : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "password is incorrect",
+		})
+	}
+
+	if emailRequest.Email == user.Email {
+		log.Println("New email is the same as the current one")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "new email is the same as the current one",
+		})
+	}
+
+	if err := i.Repositories.UpdateUserEmail(user.ID, emailRequest.Email); err != nil {
+		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"users_email_key\"") {
+			log.Println("Duplicate on email")
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error":   fiber.ErrConflict.Message,
+				"message": "email is already used by another account",
+			})
+		}
+
+		log.Printf("Failed to update the email : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditEmailChanged, models.AuditTargetUser, user.ID.String(), map[string]interface{}{
+		"from": user.Email,
+		"to":   emailRequest.Email,
+	})
+
+	user, err = i.Repositories.GetUserByID(user.ID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send the verification email : %+v", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "email changed, please verify the new address",
+		"data":    user.Profile(),
+	})
+}
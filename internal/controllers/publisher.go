@@ -5,100 +5,192 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/ravenocx/cat-socialx/config"
 	"github.com/ravenocx/cat-socialx/internal/models"
 )
 
-func PublishToRabbitMQ(cm *models.CatMatch) error {
-	urlString := fmt.Sprintf(
-		"amqp://%s:%s@%s:%s/",
-		os.Getenv("RABBITMQ_USERNAME"),
-		os.Getenv("RABBITMQ_PASSWORD"),
-		os.Getenv("RABBITMQ_HOST"),
-		os.Getenv("RABBITMQ_PORT"),
-	)
+func PublishToRabbitMQ(cfg config.RabbitMQ, cm *models.CatMatch) error {
+	body, err := json.Marshal(cm)
+	if err != nil {
+		return err
+	}
 
-	conn, err := amqp.Dial(urlString)
+	return publishToQueues(cfg, body, "cat_matches", "log")
+}
+
+// EventForwarder keeps other services receiving every event from the
+// notifications queue. One goroutine owns a long lived connection and
+// channel, reconnecting when the broker drops them, and the events wait in a
+// buffer so a slow broker never blocks the publisher.
+type EventForwarder struct {
+	cfg    config.RabbitMQ
+	events chan models.Event
+
+	conn *amqp.Connection
+	ch   *amqp.Channel
+	// No reconnection is attempted before retryAt, the events are dropped
+	// meanwhile instead of dialing the broker for each one.
+	retryAt time.Time
+}
+
+// Time to wait after a failed connection before dialing the broker again.
+const forwarderReconnectDelay = 5 * time.Second
+
+func NewEventForwarder(cfg config.RabbitMQ, size int) *EventForwarder {
+	return &EventForwarder{
+		cfg:    cfg,
+		events: make(chan models.Event, size),
+	}
+}
+
+// Forward is the subscriber of the event bus, it never blocks.
+func (f *EventForwarder) Forward(e models.Event) {
+	select {
+	case f.events <- e:
+	default:
+		log.Printf("Dropped %s event for %s, the rabbitmq forwarder is full", e.Type, e.RecipientID)
+	}
+}
+
+// Run publishes the buffered events until the stop channel is closed.
+func (f *EventForwarder) Run(stop <-chan struct{}) {
+	defer f.disconnect()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case e := <-f.events:
+			if err := f.publish(e); err != nil {
+				log.Printf("Failed to forward event to rabbitmq : %+v", err)
+			}
+		}
+	}
+}
 
+func (f *EventForwarder) publish(e models.Event) error {
+	body, err := json.Marshal(e)
 	if err != nil {
 		return err
 	}
 
-	ch, err := conn.Channel()
-	if err != nil {
+	if err := f.connect(); err != nil {
 		return err
 	}
 
-	defer ch.Close()
+	if err := publishOnChannel(f.ch, body, "notifications", "log"); err != nil {
+		// The channel is unusable after an error, start over on the next event.
+		f.disconnect()
+		return err
+	}
 
-	q, err := ch.QueueDeclare(
-		"cat_matches", // name
-		true,   // durable
-		false,   // delete when unused
-		false,   // exclusive
-		false,   // no-wait
-		nil,     // arguments
-	)
+	return nil
+}
+
+func (f *EventForwarder) connect() error {
+	if f.ch != nil && !f.ch.IsClosed() {
+		return nil
+	}
+	f.disconnect()
 
+	if time.Now().Before(f.retryAt) {
+		return fmt.Errorf("rabbitmq is unreachable, retrying at %s", f.retryAt.Format(time.RFC3339))
+	}
+
+	conn, err := amqp.Dial(f.cfg.URL())
 	if err != nil {
+		f.retryAt = time.Now().Add(forwarderReconnectDelay)
 		return err
 	}
 
-	qu, err := ch.QueueDeclare(
-		"log", // name
-		true,   // durable
-		false,   // delete when unused
-		false,   // exclusive
-		false,   // no-wait
-		nil,     // arguments
-	)
-
+	ch, err := conn.Channel()
 	if err != nil {
+		conn.Close()
+		f.retryAt = time.Now().Add(forwarderReconnectDelay)
 		return err
 	}
 
+	f.conn, f.ch = conn, ch
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
-	defer cancel()
+	return nil
+}
 
-	body, err := json.Marshal(cm)
+func (f *EventForwarder) disconnect() {
+	if f.ch != nil {
+		f.ch.Close()
+		f.ch = nil
+	}
 
-	if err != nil {
-		return err
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+}
+
+func (i *V1Repository) publishEvent(e *models.Event) {
+	if i.Events == nil {
+		return
 	}
 
-	err = ch.PublishWithContext(ctx,
-		"",     // exchange
-		q.Name, // routing key
-		false,  // mandatory
-		false,  // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType: "application/json",
-			Body:        body,
-	})
+	i.Events.Publish(*e)
+}
+
+func publishToQueues(cfg config.RabbitMQ, body []byte, queues ...string) error {
+	conn, err := amqp.Dial(cfg.URL())
 
 	if err != nil {
 		return err
 	}
 
-	err = ch.PublishWithContext(ctx,
-		"",     // exchange
-		qu.Name, // routing key
-		false,  // mandatory
-		false,  // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType: "application/json",
-			Body:        body,
-	})
+	defer conn.Close()
+
+	ch, err := conn.Channel()
 	if err != nil {
 		return err
 	}
 
+	defer ch.Close()
+
+	return publishOnChannel(ch, body, queues...)
+}
+
+func publishOnChannel(ch *amqp.Channel, body []byte, queues ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, queue := range queues {
+		q, err := ch.QueueDeclare(
+			queue, // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+
+		if err != nil {
+			return err
+		}
+
+		err = ch.PublishWithContext(ctx,
+			"",     // exchange
+			q.Name, // routing key
+			false,  // mandatory
+			false,  // immediate
+			amqp.Publishing{
+				DeliveryMode: amqp.Persistent,
+				ContentType:  "application/json",
+				Body:         body,
+			})
+
+		if err != nil {
+			return err
+		}
+	}
+
 	log.Printf(" [x] Sent to rabbitmq :  %s\n", body)
 	return nil
-}
\ No newline at end of file
+}
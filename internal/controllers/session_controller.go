@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) GetSessions(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	sessions, err := i.Repositories.GetActiveSessionsByUserId(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get the sessions : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	data := []models.SessionResponse{}
+	for _, session := range sessions {
+		data = append(data, models.SessionResponse{
+			Session: session,
+			Current: session.ID.String() == principal.SessionID,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"data":    data,
+	})
+}
+
+// RevokeSession signs a device out. Its refresh token stops working at once,
+// its access token lasts until it expires.
+func (i *V1Repository) RevokeSession(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	sessionId, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		log.Printf("Error parsing the params : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
+
+	revoked, err := i.Repositories.RevokeSession(sessionId, principal.UserID)
+	if err != nil {
+		log.Printf("Failed to revoke the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if !revoked {
+		log.Println("Session not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": "session with this ID not found",
+		})
+	}
+
+	i.recordAudit(c, &principal.UserID, models.AuditSessionRevoked, models.AuditTargetSession, sessionId.String(), nil)
+
+	return c.JSON(fiber.Map{
+		"message": "session revoked",
+	})
+}
+
+// startSession records the device signing in and issues its tokens.
+func (i *V1Repository) startSession(c *fiber.Ctx, userId uuid.UUID) (*utils.Tokens, error) {
+	sessionId := uuid.New()
+
+	tokens, err := utils.GenerateNewTokens(i.Config.JWT, i.Keys, userId.String(), sessionId.String())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	userAgent := truncate(c.Get(fiber.HeaderUserAgent), 512)
+
+	session := &models.Session{
+		ID:               sessionId,
+		UserID:           userId,
+		RefreshTokenHash: utils.HashRefreshToken(tokens.Refresh),
+		DeviceName:       deviceName(c.Get("X-Device-Name"), userAgent),
+		UserAgent:        userAgent,
+		IP:               c.IP(),
+		CreatedAt:        now,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(i.Config.JWT.RefreshTTL),
+	}
+
+	if err := i.Repositories.CreateSession(session); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// deviceName prefers the name the client sends, otherwise it is guessed from
+// the user agent.
+func deviceName(name string, userAgent string) string {
+	if name = strings.TrimSpace(name); name != "" {
+		return truncate(name, 100)
+	}
+
+	browsers := []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+		{"okhttp", "Android app"},
+		{"CFNetwork", "iOS app"},
+	}
+	systems := []struct{ token, name string }{
+		{"Android", "Android"},
+		{"iPhone", "iPhone"},
+		{"iPad", "iPad"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	}
+
+	browser, system := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, s := range systems {
+		if strings.Contains(userAgent, s.token) {
+			system = s.name
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	return "Unknown device"
+}
+
+// truncate cuts s to at most max bytes without splitting a character.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+
+	return s[:max]
+}
@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+	"github.com/valyala/fasthttp"
+)
+
+func (i *V1Repository) StreamEvents(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if i.Hub == nil {
+		log.Println("Stream hub is not configured")
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   fiber.ErrServiceUnavailable.Message,
+			"message": "event stream is not available",
+		})
+	}
+
+	hub := i.Hub
+	client := hub.Register(principal.UserID)
+
+	log.Printf("Stream client %+v connected for user %+v", client.ID, client.UserID)
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer hub.Unregister(client)
+
+		ticker := time.NewTicker(hub.HeartbeatInterval)
+		defer ticker.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", hub.HeartbeatInterval.Milliseconds())
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case e, ok := <-client.Events:
+				// The hub closes the channel when the client is swept or too slow.
+				if !ok {
+					return
+				}
+
+				data, err := json.Marshal(e)
+				if err != nil {
+					log.Printf("Failed to marshal stream event : %+v", err)
+					continue
+				}
+
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+				if err := w.Flush(); err != nil {
+					log.Printf("Stream client %+v disconnected : %+v", client.ID, err)
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": heartbeat\n\n")
+				if err := w.Flush(); err != nil {
+					log.Printf("Stream client %+v disconnected : %+v", client.ID, err)
+					return
+				}
+				hub.Touch(client)
+			}
+		}
+	}))
+
+	return nil
+}
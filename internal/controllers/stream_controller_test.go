@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/events"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// streamApp serves the stream for the given user, the principal is set the
+// way the JWT middleware does.
+func streamApp(controller *V1Repository, userId uuid.UUID) *fiber.App {
+	app := fiber.New()
+	app.Get("/v1/stream", func(c *fiber.Ctx) error {
+		if userId != uuid.Nil {
+			utils.SetPrincipal(c, &models.Principal{UserID: userId})
+		}
+		return c.Next()
+	}, controller.StreamEvents)
+
+	return app
+}
+
+func TestStreamEventsSendsDispatchedEvents(t *testing.T) {
+	hub := events.NewHub(time.Hour)
+	userId := uuid.New()
+	app := streamApp(&V1Repository{Hub: hub}, userId)
+
+	type result struct {
+		status      int
+		contentType string
+		body        string
+		err         error
+	}
+	done := make(chan result, 1)
+
+	go func() {
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/stream", nil), 5000)
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		done <- result{status: resp.StatusCode, contentType: resp.Header.Get(fiber.HeaderContentType), body: string(body), err: err}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.ConnectedClients(userId) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the stream client never registered")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	event := models.Event{Type: models.EventMatchRequested, RecipientID: userId, ActorID: uuid.New()}
+	hub.Dispatch(event)
+	hub.Dispatch(models.Event{Type: models.EventMatchApproved, RecipientID: uuid.New()})
+
+	// Sweeping far in the future disconnects the client, which ends the
+	// response once the buffered event is written.
+	hub.Sweep(time.Now().Add(24 * time.Hour))
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("app.Test : %v", res.err)
+	}
+	if res.status != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", res.status)
+	}
+	if res.contentType != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", res.contentType)
+	}
+
+	data, _ := json.Marshal(event)
+	for _, want := range []string{
+		"retry: 3600000\n\n",
+		"event: " + models.EventMatchRequested + "\ndata: " + string(data) + "\n\n",
+	} {
+		if !strings.Contains(res.body, want) {
+			t.Errorf("the stream %q doesn't contain %q", res.body, want)
+		}
+	}
+	if strings.Contains(res.body, models.EventMatchApproved) {
+		t.Errorf("the stream got the event of another user : %q", res.body)
+	}
+
+	if n := hub.ConnectedClients(userId); n != 0 {
+		t.Errorf("ConnectedClients = %d after the stream ended, want 0", n)
+	}
+}
+
+func TestStreamEventsRequiresPrincipal(t *testing.T) {
+	app := streamApp(&V1Repository{Hub: events.NewHub(time.Hour)}, uuid.Nil)
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/stream", nil))
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
+
+func TestStreamEventsWithoutHub(t *testing.T) {
+	app := streamApp(&V1Repository{}, uuid.New())
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/stream", nil))
+	if err != nil {
+		t.Fatalf("app.Test : %v", err)
+	}
+
+	if resp.StatusCode != fiber.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+}
@@ -9,81 +9,98 @@ import (
 	"github.com/ravenocx/cat-socialx/internal/utils"
 )
 
+// RenewTokens is authenticated by the refresh token alone, the access token
+// has usually expired by the time a client renews it.
 func (i *V1Repository) RenewTokens(c *fiber.Ctx) error {
-	now := time.Now().Unix()
+	renew := &models.Renew{}
 
-	claims, err := utils.ExtractTokenMetadata(c)
-	if err != nil {
-		log.Printf("Failed to extact the token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
+	if err := c.BodyParser(renew); err != nil {
+		log.Printf("Error parsing the payload :%+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
 			"message": err.Error(),
 		})
 	}
 
-	expiresAccessToken := claims.Expires
+	if renew.RefreshToken == "" {
+		log.Println("Missing refresh token")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": "refresh token is required",
+		})
+	}
+
+	refreshTokenHash := utils.HashRefreshToken(renew.RefreshToken)
 
-	if now > expiresAccessToken {
-		log.Printf("Token already expired, please renew the token : %+v", err)
+	// The refresh token has to belong to a live session, a token replaced by
+	// an earlier renewal is unknown.
+	session, err := i.Repositories.GetSessionByRefreshTokenHash(refreshTokenHash)
+	if err != nil || !session.IsActive(time.Now()) {
+		log.Printf("Refresh token doesn't match an active session : %+v", err)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
-			"message": err.Error(),
+			"message": "unauthorized, your session was ended earlier",
 		})
 	}
 
-	renew := &models.Renew{}
-
-	if err := c.BodyParser(renew); err != nil {
-		log.Printf("Error parsing the payload :%+v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   fiber.ErrBadRequest.Message,
+	user, err := i.Repositories.GetUserByID(session.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
 			"message": err.Error(),
 		})
 	}
 
-	log.Printf("Payload : %+v", renew)
+	// Revoking the tokens also revokes the sessions, this covers a session
+	// that was signed in while they were revoked.
+	if user.DeletedAt != nil || (user.TokensRevokedAt != nil && session.CreatedAt.Before(*user.TokensRevokedAt)) {
+		log.Println("Tokens of the user were revoked")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": "unauthorized, your session was revoked",
+		})
+	}
 
-	expiresRefreshToken, err := utils.ParseRefreshToken(renew.RefreshToken)
+	tokens, err := utils.GenerateNewTokens(i.Config.JWT, i.Keys, user.ID.String(), session.ID.String())
 	if err != nil {
-		log.Printf("Failed to parse the refresh token : %+v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   fiber.ErrBadRequest.Message,
+		log.Printf("Failed to generate new token : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
 			"message": err.Error(),
 		})
 	}
 
-	if now < expiresRefreshToken {
-		userID := claims.UserID
-
-		user, err := i.Repositories.GetUserByID(userID)
-		if err != nil {
-			log.Printf("Failed to get user data : %+v", err)
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-				"error":   fiber.ErrNotFound.Message,
-				"message": err.Error(),
-			})
-		}
+	now := time.Now()
 
-		tokens, err := utils.GenerateNewTokens(user.ID.String())
-		if err != nil {
-			log.Printf("Failed to generate new token : %+v", err)
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error":   fiber.ErrInternalServerError.Message,
-				"message": err.Error(),
-			})
-		}
+	session.RefreshTokenHash = utils.HashRefreshToken(tokens.Refresh)
+	session.UserAgent = truncate(c.Get(fiber.HeaderUserAgent), 512)
+	session.IP = c.IP()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(i.Config.JWT.RefreshTTL)
 
-		return c.JSON(fiber.Map{
-			"message": "success",
-			"tokens": fiber.Map{
-				"access":  tokens.Access,
-				"refresh": tokens.Refresh,
-			},
+	rotated, err := i.Repositories.RotateSession(&session, refreshTokenHash)
+	if err != nil {
+		log.Printf("Failed to rotate the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
 		})
-	} else {
+	}
+
+	if !rotated {
+		log.Println("Session was rotated or revoked meanwhile")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error":   fiber.ErrUnauthorized.Message,
 			"message": "unauthorized, your session was ended earlier",
 		})
 	}
+
+	return c.JSON(fiber.Map{
+		"message": "success",
+		"tokens": fiber.Map{
+			"access":  tokens.Access,
+			"refresh": tokens.Refresh,
+		},
+	})
 }
@@ -1,7 +1,11 @@
 package controllers
 
 import (
+	"database/sql"
+	"errors"
 	"log"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -32,7 +36,13 @@ func (i *V1Repository) UserSignUp(c *fiber.Ctx) error {
 		})
 	}
 
-	log.Printf("Payload : %+v", signUp)
+	if err := utils.ValidatePassword(i.Config.Password, signUp.Password, signUp.Email, signUp.Name); err != nil {
+		log.Printf("Password doesn't pass the policy : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": err.Error(),
+		})
+	}
 
 	user := &models.User{}
 
@@ -52,18 +62,7 @@ func (i *V1Repository) UserSignUp(c *fiber.Ctx) error {
 
 	user.CreatedAt = createdAt
 	user.UserStatus = 1
-	user.UserRole = "user"
-
-	tokens, err := utils.GenerateNewTokens(user.ID.String())
-	if err != nil {
-		log.Printf("Failed to generate new token : %+v", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error":   fiber.ErrInternalServerError.Message,
-			"message": err.Error(),
-		})
-	}
-
-	user.AccessToken = tokens.Access
+	user.UserRole = models.UserRoleUser
 
 	// Dont hash the password before validate the struct
 	user.Password = signUp.Password
@@ -75,7 +74,14 @@ func (i *V1Repository) UserSignUp(c *fiber.Ctx) error {
 			"message": utils.ValidatorErrors(err),
 		})
 	}
-	user.Password = utils.GeneratePassword(signUp.Password)
+	user.Password, err = utils.GeneratePassword(i.Config.Password, signUp.Password)
+	if err != nil {
+		log.Printf("Failed to hash the password : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
 
 	if err := i.Repositories.CreateUser(user); err != nil {
 		if strings.Contains(err.Error(), "duplicate key value violates unique constraint \"users_email_key\"") {
@@ -93,13 +99,34 @@ func (i *V1Repository) UserSignUp(c *fiber.Ctx) error {
 		})
 	}
 
+	i.recordAudit(c, &user.ID, models.AuditUserSignedUp, models.AuditTargetUser, user.ID.String(), nil)
+
+	// The session needs the user row, so the tokens are issued last.
+	tokens, err := i.startSession(c, user.ID)
+	if err != nil {
+		log.Printf("Failed to start the session : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user.AccessToken = tokens.Access
+
 	// Delete password hash field from JSON view.
 	user.Password = ""
 
+	// The account exists already, a lost email can be sent again later.
+	if err := i.sendVerificationEmail(*user); err != nil {
+		log.Printf("Failed to send the verification email : %+v", err)
+	}
+
 	responseData := models.AuthResponse{
-		Email:       user.Email,
-		Name:        user.Name,
-		AccessToken: user.AccessToken,
+		Email:         user.Email,
+		Name:          user.Name,
+		AccessToken:   user.AccessToken,
+		RefreshToken:  tokens.Refresh,
+		EmailVerified: user.IsEmailVerified(),
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
@@ -129,28 +156,97 @@ func (i *V1Repository) UserSignIn(c *fiber.Ctx) error {
 		})
 	}
 
-	log.Printf("Payload : %+v", signIn)
+	log.Printf("Sign in attempt for : %+v", signIn.Email)
+
+	ip := c.IP()
+
+	if i.LoginGuard != nil {
+		wait, err := i.LoginGuard.Check(signIn.Email, ip)
+		if err != nil {
+			// Without the counters the throttling can't be enforced, so the
+			// sign in is refused rather than let through.
+			log.Printf("Failed to check the login attempts : %+v", err)
+			i.recordFailedLogin(c, signIn.Email, nil, models.LoginFailureGuardUnavailable)
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error":   fiber.ErrServiceUnavailable.Message,
+				"message": "sign in is temporarily unavailable, please try again later",
+			})
+		}
+
+		if wait > 0 {
+			i.recordFailedLogin(c, signIn.Email, nil, models.LoginFailureThrottled)
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   fiber.ErrTooManyRequests.Message,
+				"message": "too many failed sign in attempts, please try again later",
+			})
+		}
+	}
 
+	// Unknown emails and wrong passwords get the same answer, so the endpoint
+	// can't be used to find out which emails have an account.
 	user, err := i.Repositories.GetUserByEmail(signIn.Email)
 	if err != nil {
-		log.Printf("Failed to get user data : %+v", err)
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error":   fiber.ErrNotFound.Message,
-			"message": err.Error(),
-		})
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to get user data : %+v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error":   fiber.ErrInternalServerError.Message,
+				"message": err.Error(),
+			})
+		}
+
+		utils.CompareDummyPassword(i.Config.Password, signIn.Password)
+		i.failLogin(c, signIn.Email, nil, models.LoginFailureUnknownEmail)
+		return invalidCredentials(c)
 	}
 
 	if err = utils.ComparePasswords(user.Password, signIn.Password); err != nil {
 		log.Printf("Failed to compare password : %+v", err)
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error":   fiber.ErrBadRequest.Message,
+		i.failLogin(c, signIn.Email, &user.ID, models.LoginFailureWrongPassword)
+		return invalidCredentials(c)
+	}
+
+	// Hashes made by an older hasher or weaker parameters are upgraded while
+	// the plain password is at hand, before the second step of accounts with
+	// two-factor authentication.
+	if utils.NeedsRehash(i.Config.Password, user.Password) {
+		if hash, err := utils.GeneratePassword(i.Config.Password, signIn.Password); err != nil {
+			log.Printf("Failed to rehash the password : %+v", err)
+		} else if err := i.Repositories.UpdateUserPasswordHash(user.ID, hash); err != nil {
+			log.Printf("Failed to store the rehashed password : %+v", err)
+		}
+	}
+
+	challenge, err := i.mfaChallenge(user.ID)
+	if err != nil {
+		log.Printf("Failed to get the mfa enrollment : %+v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   fiber.ErrInternalServerError.Message,
 			"message": err.Error(),
 		})
 	}
 
-	token, err := utils.GenerateNewTokens(user.ID.String())
+	// The attempt counter is only cleared once the second step passed too.
+	if challenge != nil {
+		return c.JSON(fiber.Map{
+			"message": "two-factor authentication required",
+			"data":    challenge,
+		})
+	}
+
+	if i.LoginGuard != nil {
+		if err := i.LoginGuard.Succeed(signIn.Email); err != nil {
+			log.Printf("Failed to reset the login attempts : %+v", err)
+		}
+	}
+
+	i.recordAudit(c, &user.ID, models.AuditUserLogin, models.AuditTargetUser, user.ID.String(), map[string]interface{}{
+		"method": "password",
+	})
+
+	token, err := i.startSession(c, user.ID)
 	if err != nil {
-		log.Printf("Failed to generate new token : %+v", err)
+		log.Printf("Failed to start the session : %+v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error":   fiber.ErrInternalServerError.Message,
 			"message": err.Error(),
@@ -158,9 +254,11 @@ func (i *V1Repository) UserSignIn(c *fiber.Ctx) error {
 	}
 
 	responseData := models.AuthResponse{
-		Email:       user.Email,
-		Name:        user.Name,
-		AccessToken: token.Access,
+		Email:         user.Email,
+		Name:          user.Name,
+		AccessToken:   token.Access,
+		RefreshToken:  token.Refresh,
+		EmailVerified: user.IsEmailVerified(),
 	}
 
 	return c.JSON(fiber.Map{
@@ -168,3 +266,48 @@ func (i *V1Repository) UserSignIn(c *fiber.Ctx) error {
 		"data":    responseData,
 	})
 }
+
+// failLogin counts the failure against the account and the client IP, and
+// keeps an audit record of it.
+func (i *V1Repository) failLogin(c *fiber.Ctx, email string, userId *uuid.UUID, reason string) {
+	if i.LoginGuard != nil {
+		if err := i.LoginGuard.Fail(email, c.IP()); err != nil {
+			log.Printf("Failed to count the login attempt : %+v", err)
+		}
+	}
+
+	i.recordFailedLogin(c, email, userId, reason)
+}
+
+func (i *V1Repository) recordFailedLogin(c *fiber.Ctx, email string, userId *uuid.UUID, reason string) {
+	userAgent := c.Get(fiber.HeaderUserAgent)
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	failedLogin := &models.FailedLogin{
+		ID:        uuid.New(),
+		Email:     email,
+		UserID:    userId,
+		IP:        c.IP(),
+		UserAgent: userAgent,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+
+	if err := i.Repositories.CreateFailedLogin(failedLogin); err != nil {
+		log.Printf("Failed to record the failed login : %+v", err)
+	}
+
+	i.recordAudit(c, userId, models.AuditUserLoginFailed, models.AuditTargetUser, "", map[string]interface{}{
+		"email":  email,
+		"reason": reason,
+	})
+}
+
+func invalidCredentials(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error":   fiber.ErrUnauthorized.Message,
+		"message": "invalid email or password",
+	})
+}
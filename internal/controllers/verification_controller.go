@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/mailer"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+func (i *V1Repository) VerifyEmail(c *fiber.Ctx) error {
+	verifyRequest := &models.VerifyEmailRequest{Token: c.Query("token")}
+
+	// The link in the email carries the token in the query string.
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(verifyRequest); err != nil {
+			log.Printf("Error parsing the payload :%+v", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   fiber.ErrBadRequest.Message,
+				"message": err.Error(),
+			})
+		}
+	}
+
+	validate := utils.NewValidator()
+
+	if err := validate.Struct(verifyRequest); err != nil {
+		log.Printf("Payload doesn't pass validation : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ValidatorErrors(err),
+		})
+	}
+
+	userId, email, err := utils.ParseVerificationToken(i.Config.EmailVerification, verifyRequest.Token)
+	if err != nil {
+		log.Printf("Failed to parse the verification token : %+v", err)
+		status := fiber.StatusBadRequest
+		if errors.Is(err, utils.ErrExpiredVerificationToken) {
+			status = fiber.StatusGone
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":   fiber.NewError(status).Message,
+			"message": err.Error(),
+		})
+	}
+
+	if err := i.Repositories.MarkUserEmailVerified(userId, email); err != nil {
+		log.Printf("Failed to mark the email as verified : %+v", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   fiber.ErrBadRequest.Message,
+			"message": utils.ErrInvalidVerificationToken.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "email verified successfully",
+	})
+}
+
+func (i *V1Repository) ResendVerificationEmail(c *fiber.Ctx) error {
+	principal, err := utils.CurrentPrincipal(c)
+	if err != nil {
+		log.Printf("Failed to get the principal : %+v", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error":   fiber.ErrUnauthorized.Message,
+			"message": err.Error(),
+		})
+	}
+
+	user, err := i.Repositories.GetUserByID(principal.UserID)
+	if err != nil {
+		log.Printf("Failed to get user data : %+v", err)
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error":   fiber.ErrNotFound.Message,
+			"message": err.Error(),
+		})
+	}
+
+	if user.IsEmailVerified() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":   fiber.ErrConflict.Message,
+			"message": "email is already verified",
+		})
+	}
+
+	if err := i.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send the verification email : %+v", err)
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error":   fiber.ErrServiceUnavailable.Message,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "verification email sent",
+	})
+}
+
+func (i *V1Repository) sendVerificationEmail(user models.User) error {
+	if i.Mailer == nil {
+		return errors.New("mailer is not configured")
+	}
+
+	ttl := i.Config.EmailVerification.TTL
+	token := utils.GenerateVerificationToken(i.Config.EmailVerification, user.ID, user.Email, time.Now().Add(ttl))
+
+	msg, err := mailer.Render(mailer.TemplateVerifyEmail, user.Email, mailer.TemplateData{
+		RecipientName: user.Name,
+		ActionURL:     i.BaseURL + "/v1/user/verify?token=" + url.QueryEscape(token),
+		ExpiresIn:     strconv.Itoa(int(ttl.Hours())) + " hours",
+	})
+	if err != nil {
+		return err
+	}
+
+	return i.Mailer.Enqueue(msg)
+}
+
+// requireVerifiedEmail keeps unverified accounts read-only.
+func (i *V1Repository) requireVerifiedEmail(userId uuid.UUID) *fiber.Error {
+	user, err := i.Repositories.GetUserByID(userId)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	if !user.IsEmailVerified() {
+		return fiber.NewError(fiber.StatusForbidden, "please verify your email address first")
+	}
+
+	return nil
+}
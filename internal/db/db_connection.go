@@ -1,43 +1,72 @@
 package db
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"strconv"
+	"log"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/config"
 
 	_ "github.com/jackc/pgx/v4/stdlib" // load pgx driver for PostgreSQL
 )
 
-func CreateConnection() (*sqlx.DB, error) {
-	maxConn, _ := strconv.Atoi(os.Getenv("DB_MAX_CONNECTIONS"))
-	maxIdleConn, _ := strconv.Atoi(os.Getenv("DB_MAX_IDLE_CONNECTIONS"))
-	maxLifetimeConn, _ := strconv.Atoi(os.Getenv("DB_MAX_LIFETIME_CONNECTIONS"))
-
-	url := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_SSL_MODE"),
-	)
-
-	db, err := sqlx.Connect("pgx", url)
+const (
+	// pingTimeout bounds every connection attempt.
+	pingTimeout = time.Second * 5
+	// maxConnectBackoff caps the doubling wait between attempts.
+	maxConnectBackoff = time.Second * 30
+)
+
+// CreateConnection opens the pool and pings the database. A failed attempt is
+// retried with a doubling backoff, so the app can start before the database
+// is ready, until cfg.ConnectAttempts fail or ctx is done.
+func CreateConnection(ctx context.Context, cfg config.Database) (*sqlx.DB, error) {
+	backoff := cfg.ConnectBackoff
+
+	for attempt := 1; ; attempt++ {
+		db, err := connect(ctx, cfg)
+		if err == nil {
+			return db, nil
+		}
+
+		if attempt >= cfg.ConnectAttempts {
+			return nil, fmt.Errorf("database unreachable after %d attempts, %w", attempt, err)
+		}
+
+		log.Printf("Database connection attempt %d of %d failed, retrying in %s : %+v", attempt, cfg.ConnectAttempts, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("stopped connecting to the database, %w", err)
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > maxConnectBackoff {
+			backoff = maxConnectBackoff
+		}
+	}
+}
+
+func connect(ctx context.Context, cfg config.Database) (*sqlx.DB, error) {
+	db, err := sqlx.Open("pgx", cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("error connected to database, %w", err)
 	}
 
-	db.SetMaxOpenConns(maxConn)
-	db.SetMaxIdleConns(maxIdleConn)
-	db.SetConnMaxIdleTime(time.Duration(maxLifetimeConn))
+	db.SetMaxOpenConns(cfg.MaxConnections)
+	db.SetMaxIdleConns(cfg.MaxIdleConnections)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(pingCtx); err != nil {
 		defer db.Close()
-		return nil, fmt.Errorf("error sent ping to database, %w ", err)
+		return nil, fmt.Errorf("error sent ping to database, %w", err)
 	}
 
 	return db, nil
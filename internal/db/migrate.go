@@ -0,0 +1,377 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock held while migrating, so instances
+// started together apply the migrations once.
+const migrationLockKey int64 = 0x63617473_6f63696c
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the embedded migrations ordered by version. The files
+// are named <version>_<name>.up.sql and <version>_<name>.down.sql.
+func Migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+
+	for _, file := range files {
+		base := path.Base(file)
+
+		direction := ""
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s is neither .up.sql nor .down.sql", base)
+		}
+
+		versionStr, name, found := strings.Cut(strings.TrimSuffix(base, "."+direction+".sql"), "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s has no version prefix", base)
+		}
+
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has an invalid version : %w", base, err)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", m.Name, name, version)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(a, b int) bool {
+		return migrations[a].Version < migrations[b].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrator applies the embedded migrations and records every applied version
+// in schema_versions.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up applies every pending migration and returns how many ran.
+func (m *Migrator) Up() (int, error) {
+	if len(m.migrations) == 0 {
+		return 0, nil
+	}
+
+	return m.Goto(m.migrations[len(m.migrations)-1].Version)
+}
+
+// Down reverts the last steps applied migrations.
+func (m *Migrator) Down(steps int) (int, error) {
+	if steps < 1 {
+		return 0, fmt.Errorf("steps must be at least 1")
+	}
+
+	count := 0
+	err := m.locked(func(conn *sqlx.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+			if _, ok := applied[m.migrations[i].Version]; !ok {
+				continue
+			}
+			if err := m.apply(conn, m.migrations[i], false); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Goto applies the pending migrations up to version and reverts the applied
+// ones after it, 0 reverts everything.
+func (m *Migrator) Goto(version int64) (int, error) {
+	if version != 0 && m.find(version) < 0 {
+		return 0, fmt.Errorf("no migration with version %d", version)
+	}
+
+	count := 0
+	err := m.locked(func(conn *sqlx.Conn, applied map[int64]time.Time) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if _, ok := applied[m.migrations[i].Version]; !ok || m.migrations[i].Version <= version {
+				continue
+			}
+			if err := m.apply(conn, m.migrations[i], false); err != nil {
+				return err
+			}
+			count++
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok || migration.Version > version {
+				continue
+			}
+			if err := m.apply(conn, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Status lists every migration with the time it was applied, nil when it is
+// pending.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	status := make([]MigrationStatus, 0, len(m.migrations))
+
+	err := m.locked(func(conn *sqlx.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			s := MigrationStatus{Migration: migration}
+			if appliedAt, ok := applied[migration.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+			status = append(status, s)
+		}
+
+		for version := range applied {
+			if m.find(version) < 0 {
+				log.Printf("Version %d is applied but has no migration file", version)
+			}
+		}
+
+		return nil
+	})
+
+	return status, err
+}
+
+func (m *Migrator) find(version int64) int {
+	for i, migration := range m.migrations {
+		if migration.Version == version {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// locked runs fn on a single connection holding the migration lock, the lock
+// is tied to the session that took it.
+func (m *Migrator) locked(fn func(conn *sqlx.Conn, applied map[int64]time.Time) error) error {
+	ctx := context.Background()
+
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("error taking the migration lock, %w", err)
+	}
+
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, migrationLockKey); err != nil {
+			log.Printf("Failed to release the migration lock : %+v", err)
+		}
+	}()
+
+	if err := m.ensureVersionTable(conn); err != nil {
+		return err
+	}
+
+	rows := []struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}{}
+
+	if err := conn.SelectContext(ctx, &rows, `SELECT version, applied_at FROM schema_versions`); err != nil {
+		return err
+	}
+
+	applied := map[int64]time.Time{}
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+
+	return fn(conn, applied)
+}
+
+// ensureVersionTable creates schema_versions. A database that was migrated
+// by hand with golang-migrate is adopted from its schema_migrations version
+// so its migrations don't run twice.
+func (m *Migrator) ensureVersionTable(conn *sqlx.Conn) error {
+	ctx := context.Background()
+
+	var exists bool
+	if err := conn.GetContext(ctx, &exists, `SELECT to_regclass('schema_versions') IS NOT NULL`); err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `CREATE TABLE IF NOT EXISTS schema_versions (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW ()
+	)`
+
+	if _, err := tx.Exec(query); err != nil {
+		return err
+	}
+
+	var legacy bool
+	if err := tx.Get(&legacy, `SELECT to_regclass('schema_migrations') IS NOT NULL`); err != nil {
+		return err
+	}
+
+	if legacy {
+		var state struct {
+			Version int64 `db:"version"`
+			Dirty   bool  `db:"dirty"`
+		}
+
+		err := tx.Get(&state, `SELECT version, dirty FROM schema_migrations LIMIT 1`)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if state.Dirty {
+			return fmt.Errorf("schema_migrations is dirty at version %d, fix the database before migrating", state.Version)
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version > state.Version {
+				break
+			}
+			if _, err := tx.Exec(`INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, migration.Version, migration.Name); err != nil {
+				return err
+			}
+		}
+
+		if state.Version > 0 {
+			log.Printf("Adopted schema version %d from schema_migrations", state.Version)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// apply runs one migration and records it in the same transaction, a failing
+// migration leaves neither the schema nor the version changed.
+func (m *Migrator) apply(conn *sqlx.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTxx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	script, direction := migration.Up, "up"
+	if !up {
+		script, direction = migration.Down, "down"
+	}
+
+	if strings.TrimSpace(script) == "" {
+		return fmt.Errorf("migration %d_%s has no %s file", migration.Version, migration.Name, direction)
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("error migrating %s %d_%s, %w", direction, migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_versions WHERE version = $1`, migration.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("Migrated %s %d_%s", direction, migration.Version, migration.Name)
+
+	return nil
+}
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS cats_dam_id_idx;
+DROP INDEX IF EXISTS cats_sire_id_idx;
+
+ALTER TABLE cats
+    DROP CONSTRAINT IF EXISTS cats_parents_check,
+    DROP CONSTRAINT IF EXISTS cats_dam_fk,
+    DROP CONSTRAINT IF EXISTS cats_sire_fk,
+    DROP COLUMN IF EXISTS dam_id,
+    DROP COLUMN IF EXISTS sire_id;
@@ -0,0 +1,9 @@
+ALTER TABLE cats
+    ADD COLUMN IF NOT EXISTS sire_id UUID NULL,
+    ADD COLUMN IF NOT EXISTS dam_id UUID NULL,
+    ADD CONSTRAINT cats_sire_fk FOREIGN KEY (sire_id) REFERENCES cats (id),
+    ADD CONSTRAINT cats_dam_fk FOREIGN KEY (dam_id) REFERENCES cats (id),
+    ADD CONSTRAINT cats_parents_check CHECK (sire_id IS NULL OR dam_id IS NULL OR sire_id <> dam_id);
+
+CREATE INDEX IF NOT EXISTS cats_sire_id_idx ON cats (sire_id);
+CREATE INDEX IF NOT EXISTS cats_dam_id_idx ON cats (dam_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS cats_litter_id_idx;
+
+ALTER TABLE cats
+    DROP CONSTRAINT IF EXISTS cats_litter_fk,
+    DROP COLUMN IF EXISTS litter_id;
+
+DROP TABLE IF EXISTS litters;
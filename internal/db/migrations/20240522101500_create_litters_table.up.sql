@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS litters (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    cat_match_id UUID NOT NULL,
+    sire_id UUID NOT NULL,
+    dam_id UUID NOT NULL,
+    registered_by UUID NOT NULL,
+    born_at DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    FOREIGN KEY (cat_match_id) REFERENCES cat_matches (id),
+    FOREIGN KEY (sire_id) REFERENCES cats (id),
+    FOREIGN KEY (dam_id) REFERENCES cats (id),
+    FOREIGN KEY (registered_by) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS litters_sire_id_idx ON litters (sire_id);
+CREATE INDEX IF NOT EXISTS litters_dam_id_idx ON litters (dam_id);
+
+ALTER TABLE cats
+    ADD COLUMN IF NOT EXISTS litter_id UUID NULL,
+    ADD CONSTRAINT cats_litter_fk FOREIGN KEY (litter_id) REFERENCES litters (id);
+
+CREATE INDEX IF NOT EXISTS cats_litter_id_idx ON cats (litter_id);
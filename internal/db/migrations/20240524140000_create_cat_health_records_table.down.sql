@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS cat_health_shares;
+DROP TABLE IF EXISTS cat_health_records;
+
+DROP TYPE IF EXISTS health_record_type;
@@ -0,0 +1,29 @@
+CREATE TYPE health_record_type AS ENUM('vaccination', 'vet_check', 'test_result');
+
+CREATE TABLE IF NOT EXISTS cat_health_records (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    cat_id UUID NOT NULL,
+    record_type health_record_type NOT NULL,
+    title VARCHAR(100) NOT NULL CHECK (LENGTH(title)>=1),
+    notes VARCHAR(500) NOT NULL DEFAULT '',
+    performed_at DATE NOT NULL,
+    expires_at DATE NULL CHECK (expires_at IS NULL OR expires_at >= performed_at),
+    document_urls TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    updated_at TIMESTAMP NULL,
+    deleted_at TIMESTAMP NULL,
+    FOREIGN KEY (cat_id) REFERENCES cats (id)
+);
+
+CREATE INDEX IF NOT EXISTS cat_health_records_cat_id_idx ON cat_health_records (cat_id);
+
+CREATE TABLE IF NOT EXISTS cat_health_shares (
+    cat_match_id UUID NOT NULL,
+    cat_id UUID NOT NULL,
+    shared_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    PRIMARY KEY (cat_match_id, cat_id),
+    FOREIGN KEY (cat_match_id) REFERENCES cat_matches (id) ON DELETE CASCADE,
+    FOREIGN KEY (cat_id) REFERENCES cats (id),
+    FOREIGN KEY (shared_by) REFERENCES users (id)
+);
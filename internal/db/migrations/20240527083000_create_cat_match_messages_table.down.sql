@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS cat_match_messages;
+
+-- Postgres can't drop a value from an enum, withdrawn matches become rejected ones.
+UPDATE cat_matches SET status = 'rejected' WHERE status = 'withdrawn';
@@ -0,0 +1,18 @@
+-- Migrations run in a transaction, ADD VALUE is only allowed inside one since
+-- PostgreSQL 12, which is the minimum version. The new value can't be used
+-- before the transaction commits, nothing below does.
+ALTER TYPE match_status ADD VALUE IF NOT EXISTS 'withdrawn';
+
+CREATE TABLE IF NOT EXISTS cat_match_messages (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    cat_match_id UUID NOT NULL,
+    sender_id UUID NOT NULL,
+    body VARCHAR(2000) NOT NULL CHECK (LENGTH(body)>=1),
+    read_at TIMESTAMP WITH TIME ZONE NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    deleted_at TIMESTAMP WITH TIME ZONE NULL,
+    FOREIGN KEY (cat_match_id) REFERENCES cat_matches (id) ON DELETE CASCADE,
+    FOREIGN KEY (sender_id) REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS cat_match_messages_thread_idx ON cat_match_messages (cat_match_id, created_at DESC);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key VARCHAR(300) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    locked_until TIMESTAMP WITH TIME ZONE NULL
+);
+
+CREATE TABLE IF NOT EXISTS failed_logins (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    user_id UUID NULL,
+    ip VARCHAR(45) NOT NULL,
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    reason VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS failed_logins_email_created_idx ON failed_logins (email, created_at DESC);
+CREATE INDEX IF NOT EXISTS failed_logins_ip_created_idx ON failed_logins (ip, created_at DESC);
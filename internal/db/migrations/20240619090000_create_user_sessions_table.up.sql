@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID DEFAULT uuid_generate_v4 () PRIMARY KEY,
+    user_id UUID NOT NULL,
+    refresh_token_hash VARCHAR(64) NOT NULL UNIQUE,
+    device_name VARCHAR(100) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT '',
+    ip VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW (),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_sessions_user_idx ON user_sessions (user_id);
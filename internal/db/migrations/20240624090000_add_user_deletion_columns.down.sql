@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS users_purge_after_idx;
+
+ALTER TABLE users DROP COLUMN IF EXISTS purged_at;
+ALTER TABLE users DROP COLUMN IF EXISTS purge_after;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
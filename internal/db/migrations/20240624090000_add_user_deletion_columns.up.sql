@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purge_after TIMESTAMP WITH TIME ZONE NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS purged_at TIMESTAMP WITH TIME ZONE NULL;
+
+CREATE INDEX IF NOT EXISTS users_purge_after_idx ON users (purge_after) WHERE purged_at IS NULL;
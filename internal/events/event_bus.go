@@ -0,0 +1,38 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type Handler func(e models.Event)
+
+// Bus is an in-process publish/subscribe channel for domain events. Handlers
+// run synchronously on the publishing goroutine, slow work should be moved to
+// a goroutine by the handler itself.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, h)
+}
+
+func (b *Bus) Publish(e models.Event) {
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
@@ -0,0 +1,32 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+func TestBusPublishRunsEveryHandlerInOrder(t *testing.T) {
+	bus := NewBus()
+
+	var calls []string
+	bus.Subscribe(func(e models.Event) { calls = append(calls, "first:"+e.Type) })
+	bus.Subscribe(func(e models.Event) { calls = append(calls, "second:"+e.Type) })
+
+	bus.Publish(models.Event{Type: models.EventMatchRequested, RecipientID: uuid.New()})
+
+	want := []string{"first:" + models.EventMatchRequested, "second:" + models.EventMatchRequested}
+	if len(calls) != len(want) {
+		t.Fatalf("handlers ran %v, want %v", calls, want)
+	}
+	for n := range want {
+		if calls[n] != want[n] {
+			t.Errorf("handler %d got %q, want %q", n, calls[n], want[n])
+		}
+	}
+}
+
+func TestBusPublishWithoutHandlers(t *testing.T) {
+	NewBus().Publish(models.Event{Type: models.EventMatchRequested})
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// Events buffered per client before the client is considered too slow.
+const clientBufferSize = 32
+
+type Client struct {
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Events chan models.Event
+
+	lastSeen time.Time
+	closed   bool
+}
+
+// Hub keeps the connected stream clients of every user, a user can be
+// connected from several devices at once.
+type Hub struct {
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[uuid.UUID]*Client
+
+	HeartbeatInterval time.Duration
+	ClientTimeout     time.Duration
+}
+
+func NewHub(heartbeatInterval time.Duration) *Hub {
+	return &Hub{
+		clients:           map[uuid.UUID]map[uuid.UUID]*Client{},
+		HeartbeatInterval: heartbeatInterval,
+		ClientTimeout:     3 * heartbeatInterval,
+	}
+}
+
+func (h *Hub) Register(userId uuid.UUID) *Client {
+	client := &Client{
+		ID:       uuid.New(),
+		UserID:   userId,
+		Events:   make(chan models.Event, clientBufferSize),
+		lastSeen: time.Now(),
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.clients[userId] == nil {
+		h.clients[userId] = map[uuid.UUID]*Client{}
+	}
+	h.clients[userId][client.ID] = client
+
+	return client
+}
+
+func (h *Hub) Unregister(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.remove(client)
+}
+
+// Touch records a successful write to the client, called after every heartbeat.
+func (h *Hub) Touch(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client.lastSeen = time.Now()
+}
+
+// Dispatch fans the event out to every device of the recipient. Clients whose
+// buffer is full are disconnected instead of blocking the publisher.
+func (h *Hub) Dispatch(e models.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, client := range h.clients[e.RecipientID] {
+		select {
+		case client.Events <- e:
+		default:
+			h.remove(client)
+		}
+	}
+}
+
+func (h *Hub) ConnectedClients(userId uuid.UUID) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.clients[userId])
+}
+
+// Sweep disconnects every client that missed its heartbeats.
+func (h *Hub) Sweep(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, clients := range h.clients {
+		for _, client := range clients {
+			if now.Sub(client.lastSeen) > h.ClientTimeout {
+				h.remove(client)
+			}
+		}
+	}
+}
+
+// Run sweeps stale clients until the stop channel is closed.
+func (h *Hub) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(h.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			h.Sweep(now)
+		}
+	}
+}
+
+func (h *Hub) remove(client *Client) {
+	if client.closed {
+		return
+	}
+
+	client.closed = true
+	close(client.Events)
+
+	delete(h.clients[client.UserID], client.ID)
+	if len(h.clients[client.UserID]) == 0 {
+		delete(h.clients, client.UserID)
+	}
+}
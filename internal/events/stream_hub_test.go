@@ -0,0 +1,159 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+func receive(t *testing.T, client *Client) models.Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-client.Events:
+		if !ok {
+			t.Fatal("the client was disconnected")
+		}
+		return e
+	default:
+		t.Fatal("the client received no event")
+	}
+
+	return models.Event{}
+}
+
+func assertClosed(t *testing.T, client *Client) {
+	t.Helper()
+
+	for {
+		select {
+		case _, ok := <-client.Events:
+			if !ok {
+				return
+			}
+		default:
+			t.Fatal("the client is still connected")
+		}
+	}
+}
+
+func TestHubDispatchFansOutToEveryDevice(t *testing.T) {
+	hub := NewHub(time.Second)
+	userId := uuid.New()
+
+	phone := hub.Register(userId)
+	laptop := hub.Register(userId)
+	other := hub.Register(uuid.New())
+
+	if n := hub.ConnectedClients(userId); n != 2 {
+		t.Fatalf("ConnectedClients = %d, want 2", n)
+	}
+
+	hub.Dispatch(models.Event{Type: models.EventMatchApproved, RecipientID: userId})
+
+	for _, client := range []*Client{phone, laptop} {
+		if e := receive(t, client); e.Type != models.EventMatchApproved {
+			t.Errorf("received %q, want %q", e.Type, models.EventMatchApproved)
+		}
+	}
+
+	select {
+	case e := <-other.Events:
+		t.Errorf("another user received %+v", e)
+	default:
+	}
+}
+
+func TestHubUnregister(t *testing.T) {
+	hub := NewHub(time.Second)
+	userId := uuid.New()
+
+	client := hub.Register(userId)
+	hub.Unregister(client)
+	// Unregistering twice must not close the channel again.
+	hub.Unregister(client)
+
+	assertClosed(t, client)
+	if n := hub.ConnectedClients(userId); n != 0 {
+		t.Errorf("ConnectedClients = %d, want 0", n)
+	}
+
+	// Events for a user without clients are dropped.
+	hub.Dispatch(models.Event{Type: models.EventMatchApproved, RecipientID: userId})
+}
+
+func TestHubDropsSlowClients(t *testing.T) {
+	hub := NewHub(time.Second)
+	userId := uuid.New()
+
+	slow := hub.Register(userId)
+	fast := hub.Register(userId)
+
+	for n := 0; n < clientBufferSize; n++ {
+		hub.Dispatch(models.Event{Type: models.EventMatchRequested, RecipientID: userId})
+		receive(t, fast)
+	}
+
+	// The buffer of the slow client is full, the next event disconnects it
+	// without blocking the others.
+	hub.Dispatch(models.Event{Type: models.EventMatchRejected, RecipientID: userId})
+
+	if e := receive(t, fast); e.Type != models.EventMatchRejected {
+		t.Errorf("the fast client received %q, want %q", e.Type, models.EventMatchRejected)
+	}
+	if n := hub.ConnectedClients(userId); n != 1 {
+		t.Errorf("ConnectedClients = %d, want 1", n)
+	}
+
+	// The buffered events are still delivered before the channel closes.
+	for n := 0; n < clientBufferSize; n++ {
+		receive(t, slow)
+	}
+	assertClosed(t, slow)
+}
+
+func TestHubSweepDisconnectsStaleClients(t *testing.T) {
+	hub := NewHub(time.Second)
+	userId := uuid.New()
+
+	stale := hub.Register(userId)
+	alive := hub.Register(userId)
+
+	later := time.Now().Add(hub.ClientTimeout + time.Second)
+	alive.lastSeen = later
+
+	hub.Sweep(later)
+
+	assertClosed(t, stale)
+	if n := hub.ConnectedClients(userId); n != 1 {
+		t.Fatalf("ConnectedClients = %d, want 1", n)
+	}
+
+	// Touch keeps a client alive across sweeps.
+	hub.Touch(alive)
+	hub.Sweep(time.Now())
+	if n := hub.ConnectedClients(userId); n != 1 {
+		t.Errorf("ConnectedClients = %d after a touch, want 1", n)
+	}
+}
+
+func TestHubRunStops(t *testing.T) {
+	hub := NewHub(time.Millisecond)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		hub.Run(stop)
+		close(done)
+	}()
+
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run didn't return once stopped")
+	}
+}
@@ -0,0 +1,39 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+)
+
+// AccountPurge hard deletes the accounts whose deletion grace period ended.
+type AccountPurge struct {
+	Repositories *repositories.DatabaseRepositories
+	Interval     time.Duration
+}
+
+func (j *AccountPurge) Purge(now time.Time) (int64, error) {
+	return j.Repositories.PurgeDeletedUsers(now)
+}
+
+// Run purges once on start and then on every interval until stop is closed.
+func (j *AccountPurge) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := j.Purge(time.Now())
+		if err != nil {
+			log.Printf("Failed to purge deleted accounts : %+v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d deleted accounts", purged)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+)
+
+// NotificationRetention deletes notifications older than the retention period.
+type NotificationRetention struct {
+	Repositories *repositories.DatabaseRepositories
+	Retention    time.Duration
+	Interval     time.Duration
+}
+
+func (j *NotificationRetention) Prune(now time.Time) (int64, error) {
+	return j.Repositories.DeleteNotificationsBefore(now.Add(-j.Retention))
+}
+
+// Run prunes once on start and then on every interval until stop is closed.
+func (j *NotificationRetention) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := j.Prune(time.Now())
+		if err != nil {
+			log.Printf("Failed to prune old notifications : %+v", err)
+		} else if deleted > 0 {
+			log.Printf("Pruned %d notifications older than %s", deleted, j.Retention)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
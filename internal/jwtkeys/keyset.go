@@ -0,0 +1,179 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	ErrMissingKeyID   = errors.New("token has no kid header")
+	ErrUnknownKeyID   = errors.New("token is signed with an unknown key")
+	ErrNoSigningKey   = errors.New("no active signing key")
+	ErrAlgorithmMatch = errors.New("token algorithm doesn't match its key")
+)
+
+// Key is one entry of the key set. Retired keys keep only the public part,
+// they verify the tokens they signed until those expire.
+type Key struct {
+	ID        string
+	Algorithm string
+	Private   crypto.PrivateKey
+	Public    crypto.PublicKey
+}
+
+func (k *Key) CanSign() bool {
+	return k.Private != nil
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	if k.Algorithm == AlgorithmRS256 {
+		return jwt.SigningMethodRS256
+	}
+
+	return jwt.SigningMethodEdDSA
+}
+
+// KeySet signs with the active key and verifies with any key it holds, so a
+// new key can be rolled out before the old one is retired.
+type KeySet struct {
+	mu     sync.RWMutex
+	keys   map[string]*Key
+	active string
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: map[string]*Key{}}
+}
+
+func (s *KeySet) Add(k *Key) error {
+	if k.ID == "" {
+		return errors.New("key has no id")
+	}
+
+	switch k.Public.(type) {
+	case *rsa.PublicKey:
+		k.Algorithm = AlgorithmRS256
+	case ed25519.PublicKey:
+		k.Algorithm = AlgorithmEdDSA
+	default:
+		return fmt.Errorf("key %s: unsupported key type %T", k.ID, k.Public)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, found := s.keys[k.ID]; found {
+		return fmt.Errorf("duplicate key id %s", k.ID)
+	}
+
+	s.keys[k.ID] = k
+
+	return nil
+}
+
+func (s *KeySet) SetActive(kid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k, found := s.keys[kid]
+	if !found {
+		return fmt.Errorf("active key %s not found", kid)
+	}
+
+	if !k.CanSign() {
+		return fmt.Errorf("active key %s has no private key", kid)
+	}
+
+	s.active = kid
+
+	return nil
+}
+
+func (s *KeySet) ActiveKeyID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.active
+}
+
+// Keys returns the keys sorted by id.
+func (s *KeySet) Keys() []*Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]*Key, 0, len(s.keys))
+	for _, k := range s.keys {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(a, b int) bool { return keys[a].ID < keys[b].ID })
+
+	return keys
+}
+
+// Sign signs the claims with the active key and sets its kid header.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	s.mu.RLock()
+	k, found := s.keys[s.active]
+	s.mu.RUnlock()
+
+	if !found {
+		return "", ErrNoSigningKey
+	}
+
+	token := jwt.NewWithClaims(k.method(), claims)
+	token.Header["kid"] = k.ID
+
+	return token.SignedString(k.Private)
+}
+
+// Keyfunc finds the verification key of a token by its kid header, for
+// jwt.Parse and the JWT middleware.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, ErrMissingKeyID
+	}
+
+	s.mu.RLock()
+	k, found := s.keys[kid]
+	s.mu.RUnlock()
+
+	if !found {
+		return nil, ErrUnknownKeyID
+	}
+
+	if token.Method.Alg() != k.Algorithm {
+		return nil, ErrAlgorithmMatch
+	}
+
+	return k.Public, nil
+}
+
+// GenerateEd25519 creates a key that lives in memory only.
+func GenerateEd25519() (*Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{
+		ID:      "ephemeral-" + hex.EncodeToString(public[:8]),
+		Private: private,
+		Public:  public,
+	}, nil
+}
@@ -0,0 +1,263 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		t.Fatalf("writing %s : %v", name, err)
+	}
+}
+
+func ed25519PKCS8(t *testing.T) ([]byte, ed25519.PublicKey) {
+	t.Helper()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey : %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey : %v", err)
+	}
+
+	return der, public
+}
+
+func rsaKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatalf("rsa.GenerateKey : %v", err)
+	}
+
+	return private
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	older, _ := ed25519PKCS8(t)
+	writePEM(t, dir, "2024-01-01.pem", "PRIVATE KEY", older)
+	writePEM(t, dir, "2024-06-15.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey(t, 2048)))
+
+	// A retired key only keeps its public part, it sorts last but can't sign.
+	_, retired := ed25519PKCS8(t)
+	retiredDER, _ := x509.MarshalPKIXPublicKey(retired)
+	writePEM(t, dir, "2099-retired.pem", "PUBLIC KEY", retiredDER)
+
+	s, err := LoadDir(dir, "")
+	if err != nil {
+		t.Fatalf("LoadDir : %v", err)
+	}
+
+	if s.ActiveKeyID() != "2024-06-15" {
+		t.Errorf("active key = %s, want the last private key by name", s.ActiveKeyID())
+	}
+
+	algorithms := map[string]string{}
+	for _, k := range s.Keys() {
+		algorithms[k.ID] = k.Algorithm
+	}
+	want := map[string]string{"2024-01-01": AlgorithmEdDSA, "2024-06-15": AlgorithmRS256, "2099-retired": AlgorithmEdDSA}
+	for kid, algorithm := range want {
+		if algorithms[kid] != algorithm {
+			t.Errorf("key %s algorithm = %q, want %q", kid, algorithms[kid], algorithm)
+		}
+	}
+
+	if s, err := LoadDir(dir, "2024-01-01"); err != nil || s.ActiveKeyID() != "2024-01-01" {
+		t.Errorf("LoadDir with an active kid = %v, %v, want 2024-01-01", s, err)
+	}
+	if _, err := LoadDir(dir, "2099-retired"); err == nil {
+		t.Error("LoadDir accepted a public key as the active key")
+	}
+	if _, err := LoadDir(dir, "missing"); err == nil {
+		t.Error("LoadDir accepted an unknown active key")
+	}
+}
+
+func TestLoadDirRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(t *testing.T, dir string)
+	}{
+		{"empty directory", func(t *testing.T, dir string) {}},
+		{"only public keys", func(t *testing.T, dir string) {
+			_, public := ed25519PKCS8(t)
+			der, _ := x509.MarshalPKIXPublicKey(public)
+			writePEM(t, dir, "public.pem", "PUBLIC KEY", der)
+		}},
+		{"short RSA key", func(t *testing.T, dir string) {
+			writePEM(t, dir, "short.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey(t, 1024)))
+		}},
+		{"not a PEM file", func(t *testing.T, dir string) {
+			os.WriteFile(filepath.Join(dir, "garbage.pem"), []byte("not a key"), 0o600)
+		}},
+		{"unsupported block", func(t *testing.T, dir string) {
+			writePEM(t, dir, "cert.pem", "CERTIFICATE", []byte{1, 2, 3})
+		}},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		tt.write(t, dir)
+
+		if _, err := LoadDir(dir, ""); err == nil {
+			t.Errorf("%s : LoadDir succeeded", tt.name)
+		}
+	}
+}
+
+func TestLoadWithoutDirectoryGeneratesKey(t *testing.T) {
+	s, err := Load("", "")
+	if err != nil {
+		t.Fatalf("Load : %v", err)
+	}
+
+	if len(s.Keys()) != 1 || !s.Keys()[0].CanSign() || s.ActiveKeyID() != s.Keys()[0].ID {
+		t.Errorf("keys = %+v, want one active ephemeral key", s.Keys())
+	}
+}
+
+func parse(s *KeySet, token string) error {
+	_, err := jwt.Parse(token, s.Keyfunc, jwt.WithValidMethods([]string{AlgorithmRS256, AlgorithmEdDSA}))
+
+	return err
+}
+
+func TestKeyRotation(t *testing.T) {
+	s := NewKeySet()
+
+	old, _ := GenerateEd25519()
+	old.ID = "2024-01-01"
+	if err := s.Add(old); err != nil {
+		t.Fatalf("Add : %v", err)
+	}
+	if err := s.SetActive(old.ID); err != nil {
+		t.Fatalf("SetActive : %v", err)
+	}
+
+	oldToken, err := s.Sign(jwt.MapClaims{"sub": "jane"})
+	if err != nil {
+		t.Fatalf("Sign : %v", err)
+	}
+
+	private := rsaKey(t, 2048)
+	if err := s.Add(&Key{ID: "2024-06-15", Private: private, Public: &private.PublicKey}); err != nil {
+		t.Fatalf("Add : %v", err)
+	}
+	if err := s.SetActive("2024-06-15"); err != nil {
+		t.Fatalf("SetActive : %v", err)
+	}
+
+	newToken, _ := s.Sign(jwt.MapClaims{"sub": "jane"})
+
+	// Tokens of the previous key stay valid during the rotation.
+	if err := parse(s, oldToken); err != nil {
+		t.Errorf("token of the previous key : %v", err)
+	}
+	if err := parse(s, newToken); err != nil {
+		t.Errorf("token of the active key : %v", err)
+	}
+
+	parsed, _, _ := new(jwt.Parser).ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != "2024-06-15" || parsed.Method.Alg() != AlgorithmRS256 {
+		t.Errorf("header = %v, want the kid and algorithm of the active key", parsed.Header)
+	}
+
+	// Once retired from the set, the previous key verifies nothing.
+	retired := NewKeySet()
+	retired.Add(&Key{ID: "2024-06-15", Public: &private.PublicKey})
+	if err := parse(retired, oldToken); !errors.Is(err, ErrUnknownKeyID) {
+		t.Errorf("token of a removed key = %v, want ErrUnknownKeyID", err)
+	}
+	if _, err := retired.Sign(jwt.MapClaims{}); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("Sign without an active key = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestKeyfuncRejectsForgedHeaders(t *testing.T) {
+	s := NewKeySet()
+	k, _ := GenerateEd25519()
+	s.Add(k)
+	s.SetActive(k.ID)
+
+	// No kid header.
+	noKid, _ := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{}).SignedString(k.Private)
+	if err := parse(s, noKid); !errors.Is(err, ErrMissingKeyID) {
+		t.Errorf("token without kid = %v, want ErrMissingKeyID", err)
+	}
+
+	// The kid of an Ed25519 key with an RS256 signature.
+	private := rsaKey(t, 2048)
+	confused := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{})
+	confused.Header["kid"] = k.ID
+	confusedToken, _ := confused.SignedString(private)
+	if err := parse(s, confusedToken); !errors.Is(err, ErrAlgorithmMatch) {
+		t.Errorf("token with another algorithm = %v, want ErrAlgorithmMatch", err)
+	}
+
+	// HMAC with the public key as secret is refused before the key lookup.
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{})
+	hmac.Header["kid"] = k.ID
+	hmacToken, _ := hmac.SignedString([]byte(k.Public.(ed25519.PublicKey)))
+	if err := parse(s, hmacToken); err == nil {
+		t.Error("HS256 token was accepted")
+	}
+}
+
+func TestKeySetAdd(t *testing.T) {
+	s := NewKeySet()
+	k, _ := GenerateEd25519()
+
+	if err := s.Add(k); err != nil {
+		t.Fatalf("Add : %v", err)
+	}
+	if err := s.Add(k); err == nil {
+		t.Error("Add accepted a duplicate kid")
+	}
+	if err := s.Add(&Key{Public: k.Public}); err == nil {
+		t.Error("Add accepted a key without id")
+	}
+	if err := s.Add(&Key{ID: "other", Public: "not a key"}); err == nil {
+		t.Error("Add accepted an unsupported key type")
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	s := NewKeySet()
+	ed, _ := GenerateEd25519()
+	ed.ID = "a"
+	private := rsaKey(t, 2048)
+	s.Add(ed)
+	s.Add(&Key{ID: "b", Public: &private.PublicKey})
+
+	set := s.JWKS()
+	if len(set.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want 2", len(set.Keys))
+	}
+
+	if k := set.Keys[0]; k.KeyID != "a" || k.KeyType != "OKP" || k.Curve != "Ed25519" || k.Algorithm != AlgorithmEdDSA || k.X == "" || k.Use != "sig" {
+		t.Errorf("Ed25519 JWK = %+v", k)
+	}
+	if k := set.Keys[1]; k.KeyID != "b" || k.KeyType != "RSA" || k.Algorithm != AlgorithmRS256 || k.N == "" || k.E != "AQAB" {
+		t.Errorf("RSA JWK = %+v", k)
+	}
+}
@@ -0,0 +1,133 @@
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Load loads the keys of dir and signs with activeKid. Without a directory an in-memory key is generated, tokens then stop working
+// on restart and aren't shared between instances, which is only fine for
+// development.
+func Load(dir string, activeKid string) (*KeySet, error) {
+	if dir == "" {
+		log.Println("No JWT keys directory is set, signing tokens with an ephemeral key")
+
+		k, err := GenerateEd25519()
+		if err != nil {
+			return nil, err
+		}
+
+		s := NewKeySet()
+		if err := s.Add(k); err != nil {
+			return nil, err
+		}
+
+		return s, s.SetActive(k.ID)
+	}
+
+	return LoadDir(dir, activeKid)
+}
+
+// LoadDir reads every *.pem file of the directory, the file name is the kid.
+// Private keys (PKCS#8, PKCS#1) can sign, public keys (PKIX) only verify.
+// Without an active kid the last private key by name signs, so date based
+// names like 2024-06-15.pem rotate by adding a file.
+func LoadDir(dir string, activeKid string) (*KeySet, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no *.pem key found in %s", dir)
+	}
+
+	s := NewKeySet()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		k, err := ParsePEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		k.ID = strings.TrimSuffix(filepath.Base(path), ".pem")
+
+		if err := s.Add(k); err != nil {
+			return nil, err
+		}
+
+		if activeKid == "" && k.CanSign() && k.ID > s.active {
+			s.active = k.ID
+		}
+	}
+
+	if activeKid != "" {
+		if err := s.SetActive(activeKid); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.active == "" {
+		return nil, ErrNoSigningKey
+	}
+
+	return s, nil
+}
+
+func ParsePEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return privateKey(private)
+	case "RSA PRIVATE KEY":
+		private, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return privateKey(private)
+	case "PUBLIC KEY":
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return &Key{Public: public}, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %s", block.Type)
+	}
+}
+
+func privateKey(private crypto.PrivateKey) (*Key, error) {
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if private.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys need at least 2048 bits")
+		}
+		return &Key{Private: private, Public: &private.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{Private: private, Public: private.Public()}, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+}
@@ -0,0 +1,169 @@
+package loginguard
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// Store keeps the attempt counters. A missing key is returned as a zero
+// LoginAttempt. Concurrent failures, possibly from several instances, must
+// never be lost, so the counter is incremented by the store in one step.
+type Store interface {
+	GetLoginAttempt(key string) (models.LoginAttempt, error)
+	// IncrementLoginFailures adds a failure at now and returns the counter. A
+	// counter that can start over (see CounterExpired) restarts at one.
+	IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (models.LoginAttempt, error)
+	LockLoginAttempt(key string, until time.Time) error
+	DeleteLoginAttempt(key string) error
+}
+
+// Policy describes how a key is slowed down. The first FreeAttempts failures
+// cost nothing, every next one doubles the wait from BaseDelay up to MaxDelay,
+// and LockoutThreshold failures lock the key for LockoutDuration. Counters
+// start over after ResetAfter without failures.
+type Policy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	ResetAfter       time.Duration
+}
+
+// Guard throttles the logins per account and per client IP.
+type Guard struct {
+	Store   Store
+	Account Policy
+	IP      Policy
+	// Now is the clock of the guard, replaced by the tests.
+	Now func() time.Time
+}
+
+func New(store Store) *Guard {
+	return &Guard{
+		Store: store,
+		Now:   time.Now,
+		Account: Policy{
+			FreeAttempts:     3,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute * 5,
+			LockoutThreshold: 10,
+			LockoutDuration:  time.Minute * 15,
+			ResetAfter:       time.Hour,
+		},
+		IP: Policy{
+			FreeAttempts:     20,
+			BaseDelay:        time.Second,
+			MaxDelay:         time.Minute,
+			LockoutThreshold: 100,
+			LockoutDuration:  time.Minute * 15,
+			ResetAfter:       time.Hour,
+		},
+	}
+}
+
+// Check returns how long the client has to wait before the next attempt, zero
+// when it can try now.
+func (g *Guard) Check(email string, ip string) (time.Duration, error) {
+	now := g.Now()
+
+	account, err := g.Store.GetLoginAttempt(accountKey(email))
+	if err != nil {
+		return 0, err
+	}
+
+	client, err := g.Store.GetLoginAttempt(ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	wait := g.Account.wait(account, now)
+	if ipWait := g.IP.wait(client, now); ipWait > wait {
+		wait = ipWait
+	}
+
+	return wait, nil
+}
+
+func (g *Guard) Fail(email string, ip string) error {
+	now := g.Now()
+
+	if err := g.fail(accountKey(email), g.Account, now); err != nil {
+		return err
+	}
+
+	return g.fail(ipKey(ip), g.IP, now)
+}
+
+// Succeed clears the counter of the account. The IP counter is kept, one valid
+// account must not reset the budget of a client trying many others.
+func (g *Guard) Succeed(email string) error {
+	return g.Store.DeleteLoginAttempt(accountKey(email))
+}
+
+func (g *Guard) fail(key string, policy Policy, now time.Time) error {
+	attempt, err := g.Store.IncrementLoginFailures(key, now, policy.ResetAfter)
+	if err != nil {
+		return err
+	}
+
+	// Racing failures may both lock the key, which is harmless.
+	if policy.LockoutThreshold > 0 && attempt.Failures >= policy.LockoutThreshold {
+		return g.Store.LockLoginAttempt(key, now.Add(policy.LockoutDuration))
+	}
+
+	return nil
+}
+
+func (p Policy) wait(a models.LoginAttempt, now time.Time) time.Duration {
+	if a.LockedUntil != nil && now.Before(*a.LockedUntil) {
+		return a.LockedUntil.Sub(now)
+	}
+
+	if p.expired(a, now) || a.Failures <= p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for n := p.FreeAttempts + 1; n < a.Failures && delay < p.MaxDelay; n++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	if next := a.LastFailureAt.Add(delay); now.Before(next) {
+		return next.Sub(now)
+	}
+
+	return 0
+}
+
+func (p Policy) expired(a models.LoginAttempt, now time.Time) bool {
+	return CounterExpired(a, now, p.ResetAfter)
+}
+
+// CounterExpired tells whether the counter can start over, after a served
+// lockout or a quiet period of resetAfter.
+func CounterExpired(a models.LoginAttempt, now time.Time, resetAfter time.Duration) bool {
+	if a.Failures == 0 {
+		return true
+	}
+
+	if a.LockedUntil != nil {
+		return !now.Before(*a.LockedUntil)
+	}
+
+	return now.Sub(a.LastFailureAt) > resetAfter
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,211 @@
+package loginguard
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// clock is moved by hand, the guard reads it through Now.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newTestGuard uses the default policies of New with an in-memory store.
+func newTestGuard() (*Guard, *MemoryStore, *clock) {
+	store := NewMemoryStore()
+	c := &clock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+
+	guard := New(store)
+	guard.Now = c.Now
+
+	return guard, store, c
+}
+
+func mustWait(t *testing.T, g *Guard, email string, ip string, want time.Duration) {
+	t.Helper()
+
+	wait, err := g.Check(email, ip)
+	if err != nil {
+		t.Fatalf("Check : %v", err)
+	}
+
+	if wait != want {
+		t.Fatalf("Check = %s, want %s", wait, want)
+	}
+}
+
+func mustFail(t *testing.T, g *Guard, email string, ip string, times int) {
+	t.Helper()
+
+	for n := 0; n < times; n++ {
+		if err := g.Fail(email, ip); err != nil {
+			t.Fatalf("Fail : %v", err)
+		}
+	}
+}
+
+func TestGuardBackoff(t *testing.T) {
+	g, _, c := newTestGuard()
+
+	// The free attempts cost nothing.
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 3)
+	mustWait(t, g, "jane@example.com", "10.0.0.1", 0)
+
+	// Then the wait doubles from one second.
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+		mustFail(t, g, "jane@example.com", "10.0.0.1", 1)
+		mustWait(t, g, "jane@example.com", "10.0.0.1", delay)
+
+		c.Advance(delay - time.Millisecond)
+		mustWait(t, g, "jane@example.com", "10.0.0.1", time.Millisecond)
+
+		c.Advance(time.Millisecond)
+		mustWait(t, g, "jane@example.com", "10.0.0.1", 0)
+	}
+}
+
+func TestGuardBackoffIsCapped(t *testing.T) {
+	g, _, _ := newTestGuard()
+	g.Account.LockoutThreshold = 0
+
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 30)
+	mustWait(t, g, "jane@example.com", "10.0.0.1", g.Account.MaxDelay)
+}
+
+func TestGuardLockout(t *testing.T) {
+	g, store, c := newTestGuard()
+
+	mustFail(t, g, "jane@example.com", "10.0.0.1", g.Account.LockoutThreshold)
+	mustWait(t, g, "jane@example.com", "10.0.0.1", g.Account.LockoutDuration)
+
+	c.Advance(g.Account.LockoutDuration - time.Second)
+	mustWait(t, g, "jane@example.com", "10.0.0.1", time.Second)
+
+	// A served lockout starts the counter over.
+	c.Advance(time.Second)
+	mustWait(t, g, "jane@example.com", "10.0.0.1", 0)
+
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 1)
+	attempt, _ := store.GetLoginAttempt(accountKey("jane@example.com"))
+	if attempt.Failures != 1 || attempt.LockedUntil != nil {
+		t.Errorf("counter after the lockout = %+v, want one failure and no lock", attempt)
+	}
+}
+
+func TestGuardResetsAfterQuietPeriod(t *testing.T) {
+	g, store, c := newTestGuard()
+
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 6)
+
+	c.Advance(g.Account.ResetAfter)
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 1)
+	if attempt, _ := store.GetLoginAttempt(accountKey("jane@example.com")); attempt.Failures != 7 {
+		t.Fatalf("failures right at ResetAfter = %d, want 7", attempt.Failures)
+	}
+
+	c.Advance(g.Account.ResetAfter + time.Second)
+	mustFail(t, g, "jane@example.com", "10.0.0.1", 1)
+	if attempt, _ := store.GetLoginAttempt(accountKey("jane@example.com")); attempt.Failures != 1 {
+		t.Errorf("failures after the quiet period = %d, want 1", attempt.Failures)
+	}
+	mustWait(t, g, "jane@example.com", "10.0.0.1", 0)
+}
+
+func TestGuardSucceedKeepsIPCounter(t *testing.T) {
+	g, store, _ := newTestGuard()
+
+	mustFail(t, g, "Jane@Example.com ", "10.0.0.1", 5)
+
+	if err := g.Succeed("jane@example.com"); err != nil {
+		t.Fatalf("Succeed : %v", err)
+	}
+
+	if attempt, _ := store.GetLoginAttempt(accountKey("jane@example.com")); attempt.Failures != 0 {
+		t.Errorf("account failures after Succeed = %d, want 0", attempt.Failures)
+	}
+	if attempt, _ := store.GetLoginAttempt(ipKey("10.0.0.1")); attempt.Failures != 5 {
+		t.Errorf("IP failures after Succeed = %d, want 5", attempt.Failures)
+	}
+}
+
+func TestGuardThrottlesIPAcrossAccounts(t *testing.T) {
+	g, _, _ := newTestGuard()
+
+	for n := 0; n <= g.IP.FreeAttempts; n++ {
+		mustFail(t, g, string(rune('a'+n))+"@example.com", "10.0.0.1", 1)
+	}
+
+	// A new account from the same client waits, another client doesn't.
+	mustWait(t, g, "new@example.com", "10.0.0.1", g.IP.BaseDelay)
+	mustWait(t, g, "new@example.com", "10.0.0.2", 0)
+}
+
+func TestGuardCountsConcurrentFailures(t *testing.T) {
+	g, store, _ := newTestGuard()
+	g.Account.LockoutThreshold = 0
+
+	var wg sync.WaitGroup
+	for n := 0; n < 50; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.Fail("jane@example.com", "10.0.0.1"); err != nil {
+				t.Errorf("Fail : %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if attempt, _ := store.GetLoginAttempt(accountKey("jane@example.com")); attempt.Failures != 50 {
+		t.Errorf("failures = %d, want 50", attempt.Failures)
+	}
+}
+
+// failingStore can't read its counters.
+type failingStore struct {
+	*MemoryStore
+}
+
+var errStoreDown = errors.New("store is down")
+
+func (s failingStore) GetLoginAttempt(key string) (models.LoginAttempt, error) {
+	return models.LoginAttempt{}, errStoreDown
+}
+
+func TestGuardCheckReportsStoreErrors(t *testing.T) {
+	g := New(failingStore{NewMemoryStore()})
+
+	if _, err := g.Check("jane@example.com", "10.0.0.1"); !errors.Is(err, errStoreDown) {
+		t.Errorf("Check error = %v, want the store error", err)
+	}
+}
+
+func TestMemoryStoreSweepsOldCounters(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	store.IncrementLoginFailures("old", now, time.Hour)
+	store.IncrementLoginFailures("locked", now, time.Hour)
+	store.LockLoginAttempt("locked", now.Add(store.MaxAge*2))
+
+	store.IncrementLoginFailures("new", now.Add(store.MaxAge+time.Hour), time.Hour)
+
+	if attempt, _ := store.GetLoginAttempt("old"); attempt.Failures != 0 {
+		t.Errorf("old counter = %+v, want it swept", attempt)
+	}
+	if attempt, _ := store.GetLoginAttempt("locked"); attempt.Failures != 1 {
+		t.Errorf("locked counter = %+v, want it kept until the lock ends", attempt)
+	}
+}
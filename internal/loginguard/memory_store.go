@@ -0,0 +1,92 @@
+package loginguard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// MemoryStore keeps the counters in the process. They are lost on restart and
+// not shared between instances, use the Postgres store for that.
+type MemoryStore struct {
+	// Entries without failures for this long are dropped.
+	MaxAge time.Duration
+
+	mu        sync.Mutex
+	attempts  map[string]models.LoginAttempt
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		MaxAge:   time.Hour * 24,
+		attempts: map[string]models.LoginAttempt{},
+	}
+}
+
+func (s *MemoryStore) GetLoginAttempt(key string) (models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[key], nil
+}
+
+func (s *MemoryStore) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (models.LoginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt := s.attempts[key]
+	if CounterExpired(attempt, now, resetAfter) {
+		attempt = models.LoginAttempt{}
+	}
+
+	attempt.Key = key
+	attempt.Failures++
+	attempt.LastFailureAt = now
+
+	s.attempts[key] = attempt
+	s.sweep(now)
+
+	return attempt, nil
+}
+
+func (s *MemoryStore) LockLoginAttempt(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempt, ok := s.attempts[key]
+	if !ok {
+		return nil
+	}
+
+	attempt.LockedUntil = &until
+	s.attempts[key] = attempt
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteLoginAttempt(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute*10 {
+		return
+	}
+	s.lastSweep = now
+
+	for key, a := range s.attempts {
+		if a.LockedUntil != nil && now.Before(*a.LockedUntil) {
+			continue
+		}
+		if now.Sub(a.LastFailureAt) > s.MaxAge {
+			delete(s.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LogMailer only prints the emails, used in development.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, from string, msg Message) error {
+	log.Printf("Email from %s to %s : %s\n%s", from, msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// FileMailer writes every email as an .eml file in Dir, handy to inspect the
+// rendered templates.
+type FileMailer struct {
+	Dir string
+}
+
+func (m *FileMailer) Send(ctx context.Context, from string, msg Message) error {
+	raw, err := buildMIME(from, msg)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102T150405"), uuid.New())
+
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o644)
+}
@@ -0,0 +1,106 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLogMailerSend(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	m := &LogMailer{}
+	msg := Message{To: "jane@example.com", Subject: "Hello", Text: "plain body"}
+
+	if err := m.Send(context.Background(), "no-reply@example.com", msg); err != nil {
+		t.Fatalf("Send : %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"no-reply@example.com", "jane@example.com", "Hello", "plain body"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("the log %q doesn't contain %q", out, want)
+		}
+	}
+}
+
+func TestFileMailerSend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := &FileMailer{Dir: dir}
+
+	msg := Message{
+		To:      "jane@example.com",
+		Subject: "Héllo",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<http://example.com/unsubscribe>"},
+	}
+
+	if err := m.Send(context.Background(), "no-reply@example.com", msg); err != nil {
+		t.Fatalf("Send : %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("found %v (%v), want one .eml file", files, err)
+	}
+
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("read : %v", err)
+	}
+
+	email, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("the file isn't a valid email : %v", err)
+	}
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(email.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if got := email.Header.Get("To"); got != msg.To {
+		t.Errorf("To = %q, want %q", got, msg.To)
+	}
+	if got := email.Header.Get("List-Unsubscribe"); got != msg.Headers["List-Unsubscribe"] {
+		t.Errorf("List-Unsubscribe = %q, want %q", got, msg.Headers["List-Unsubscribe"])
+	}
+
+	mediaType, params, err := mime.ParseMediaType(email.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/alternative", mediaType, err)
+	}
+
+	parts := map[string]string{}
+	reader := multipart.NewReader(email.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("read part : %v", err)
+		}
+
+		// The multipart reader decodes the quoted-printable parts.
+		body, _ := io.ReadAll(part)
+		parts[part.Header.Get("Content-Type")] = string(body)
+	}
+
+	if got := parts["text/plain; charset=utf-8"]; got != msg.Text {
+		t.Errorf("text part = %q, want %q", got, msg.Text)
+	}
+	if got := parts["text/html; charset=utf-8"]; got != msg.HTML {
+		t.Errorf("HTML part = %q, want %q", got, msg.HTML)
+	}
+}
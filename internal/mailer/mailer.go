@@ -0,0 +1,81 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Extra headers, e.g. List-Unsubscribe.
+	Headers map[string]string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, from string, msg Message) error
+}
+
+// buildMIME renders the message as a multipart/alternative email with a text
+// and an HTML part.
+func buildMIME(from string, msg Message) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{contentType: "text/plain; charset=utf-8", content: msg.Text},
+		{contentType: "text/html; charset=utf-8", content: msg.HTML},
+	} {
+		if part.content == "" {
+			continue
+		}
+
+		w, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var raw bytes.Buffer
+	fmt.Fprintf(&raw, "From: %s\r\n", from)
+	fmt.Fprintf(&raw, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&raw, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&raw, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&raw, "Message-ID: <%s@cat-socialx>\r\n", uuid.New())
+	fmt.Fprint(&raw, "MIME-Version: 1.0\r\n")
+	for key, value := range msg.Headers {
+		fmt.Fprintf(&raw, "%s: %s\r\n", key, value)
+	}
+	fmt.Fprintf(&raw, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", writer.Boundary())
+	raw.Write(body.Bytes())
+
+	return raw.Bytes(), nil
+}
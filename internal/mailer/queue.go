@@ -0,0 +1,99 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+var ErrQueueFull = errors.New("mail queue is full")
+
+// Queue sends emails in the background and retries failed deliveries with an
+// exponential backoff, so a slow SMTP server never blocks a request.
+type Queue struct {
+	Mailer      Mailer
+	From        string
+	MaxAttempts int
+	Backoff     time.Duration
+	Timeout     time.Duration
+
+	jobs chan Message
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewQueue(m Mailer, from string, size int) *Queue {
+	return &Queue{
+		Mailer:      m,
+		From:        from,
+		MaxAttempts: 5,
+		Backoff:     2 * time.Second,
+		Timeout:     30 * time.Second,
+		jobs:        make(chan Message, size),
+		stop:        make(chan struct{}),
+	}
+}
+
+func (q *Queue) Enqueue(msg Message) error {
+	select {
+	case q.jobs <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+func (q *Queue) Start(workers int) {
+	for n := 0; n < workers; n++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for {
+				select {
+				case <-q.stop:
+					return
+				case msg := <-q.jobs:
+					q.deliver(msg)
+				}
+			}
+		}()
+	}
+}
+
+// Close stops the workers and waits for the deliveries in progress, emails
+// still waiting in the queue are dropped.
+func (q *Queue) Close() {
+	close(q.stop)
+	q.wg.Wait()
+}
+
+func (q *Queue) deliver(msg Message) {
+	backoff := q.Backoff
+
+	for attempt := 1; attempt <= q.MaxAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), q.Timeout)
+		err := q.Mailer.Send(ctx, q.From, msg)
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		log.Printf("Failed to send email to %s (attempt %d/%d) : %+v", msg.To, attempt, q.MaxAttempts, err)
+
+		if attempt == q.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	log.Printf("Giving up sending email to %s : %s", msg.To, msg.Subject)
+}
@@ -0,0 +1,154 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMailer fails the first failures sends and records when each send ran.
+type fakeMailer struct {
+	mu       sync.Mutex
+	failures int
+	calls    []time.Time
+	sent     []Message
+	done     chan struct{}
+}
+
+func newFakeMailer(failures int) *fakeMailer {
+	return &fakeMailer{failures: failures, done: make(chan struct{}, 10)}
+}
+
+func (m *fakeMailer) Send(ctx context.Context, from string, msg Message) error {
+	m.mu.Lock()
+	defer func() {
+		m.mu.Unlock()
+		m.done <- struct{}{}
+	}()
+
+	m.calls = append(m.calls, time.Now())
+	if len(m.calls) <= m.failures {
+		return errors.New("smtp server unavailable")
+	}
+
+	m.sent = append(m.sent, msg)
+
+	return nil
+}
+
+func (m *fakeMailer) snapshot() ([]time.Time, []Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]time.Time{}, m.calls...), append([]Message{}, m.sent...)
+}
+
+func waitCalls(t *testing.T, m *fakeMailer, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-m.done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d send attempts ran", i, n)
+		}
+	}
+}
+
+func newTestQueue(m Mailer, size int) *Queue {
+	q := NewQueue(m, "no-reply@example.com", size)
+	q.MaxAttempts = 3
+	q.Backoff = 20 * time.Millisecond
+	q.Timeout = time.Second
+
+	return q
+}
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	m := newFakeMailer(2)
+	q := newTestQueue(m, 1)
+	q.Start(1)
+	defer q.Close()
+
+	if err := q.Enqueue(Message{To: "jane@example.com"}); err != nil {
+		t.Fatalf("Enqueue : %v", err)
+	}
+
+	waitCalls(t, m, 3)
+
+	calls, sent := m.snapshot()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want 1 after two failures", len(sent))
+	}
+
+	// The backoff doubles after each failure.
+	if gap := calls[1].Sub(calls[0]); gap < q.Backoff {
+		t.Errorf("first retry after %v, want at least %v", gap, q.Backoff)
+	}
+	if gap := calls[2].Sub(calls[1]); gap < 2*q.Backoff {
+		t.Errorf("second retry after %v, want at least %v", gap, 2*q.Backoff)
+	}
+}
+
+func TestQueueGivesUpAfterMaxAttempts(t *testing.T) {
+	m := newFakeMailer(10)
+	q := newTestQueue(m, 1)
+	q.Start(1)
+
+	if err := q.Enqueue(Message{To: "jane@example.com"}); err != nil {
+		t.Fatalf("Enqueue : %v", err)
+	}
+
+	waitCalls(t, m, q.MaxAttempts)
+
+	// No further attempt once the last one failed.
+	select {
+	case <-m.done:
+		t.Fatal("the queue retried past MaxAttempts")
+	case <-time.After(8 * q.Backoff):
+	}
+	q.Close()
+
+	if calls, sent := m.snapshot(); len(calls) != q.MaxAttempts || len(sent) != 0 {
+		t.Errorf("got %d attempts and %d sent, want %d attempts and none sent", len(calls), len(sent), q.MaxAttempts)
+	}
+}
+
+func TestQueueEnqueueFull(t *testing.T) {
+	q := newTestQueue(newFakeMailer(0), 1)
+
+	if err := q.Enqueue(Message{To: "jane@example.com"}); err != nil {
+		t.Fatalf("Enqueue : %v", err)
+	}
+
+	if err := q.Enqueue(Message{To: "john@example.com"}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Enqueue on a full queue = %v, want ErrQueueFull", err)
+	}
+}
+
+func TestQueueCloseStopsBackoff(t *testing.T) {
+	m := newFakeMailer(10)
+	q := newTestQueue(m, 1)
+	q.Backoff = time.Hour
+	q.Start(1)
+
+	if err := q.Enqueue(Message{To: "jane@example.com"}); err != nil {
+		t.Fatalf("Enqueue : %v", err)
+	}
+
+	waitCalls(t, m, 1)
+
+	closed := make(chan struct{})
+	go func() {
+		q.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waited for the backoff")
+	}
+}
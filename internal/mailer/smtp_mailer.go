@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, from string, msg Message) error {
+	raw, err := buildMIME(from, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	// smtp.SendMail has no context support, run it aside so a cancelled
+	// context still returns on time.
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(net.JoinHostPort(m.Host, m.Port), auth, envelopeAddress(from), []string{envelopeAddress(msg.To)}, raw)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-done:
+		return err
+	}
+}
+
+// envelopeAddress strips the display name of "Name <address>".
+func envelopeAddress(address string) string {
+	if start := strings.LastIndex(address, "<"); start >= 0 {
+		if end := strings.LastIndex(address, ">"); end > start {
+			return address[start+1 : end]
+		}
+	}
+
+	return strings.TrimSpace(address)
+}
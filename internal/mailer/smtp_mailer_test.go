@@ -0,0 +1,172 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake server received during one connection.
+type smtpSession struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTPServer accepts one connection and speaks just enough SMTP for
+// net/smtp, the session is sent on the returned channel once the client quits.
+func fakeSMTPServer(t *testing.T) (string, string, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen : %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := textproto.NewReader(bufio.NewReader(conn))
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var session smtpSession
+		reply("220 localhost ESMTP")
+
+		for {
+			line, err := reader.ReadLine()
+			if err != nil {
+				return
+			}
+
+			verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case verb == "EHLO" || verb == "HELO":
+				reply("250 localhost")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				session.From = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				session.To = append(session.To, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case verb == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				lines, err := reader.ReadDotLines()
+				if err != nil {
+					return
+				}
+				session.Data = strings.Join(lines, "\n")
+				reply("250 OK")
+			case verb == "QUIT":
+				reply("221 Bye")
+				sessions <- session
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	return host, port, sessions
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, sessions := fakeSMTPServer(t)
+	m := &SMTPMailer{Host: host, Port: port}
+
+	msg := Message{
+		To:      "Jane <jane@example.com>",
+		Subject: "Hello",
+		Text:    "plain body",
+		HTML:    "<p>html body</p>",
+		Headers: map[string]string{"List-Unsubscribe": "<http://example.com/unsubscribe>"},
+	}
+
+	if err := m.Send(context.Background(), "Cat Social <no-reply@example.com>", msg); err != nil {
+		t.Fatalf("Send : %v", err)
+	}
+
+	select {
+	case session := <-sessions:
+		if session.From != "no-reply@example.com" {
+			t.Errorf("MAIL FROM = %q, want the bare address", session.From)
+		}
+		if len(session.To) != 1 || session.To[0] != "jane@example.com" {
+			t.Errorf("RCPT TO = %v, want [jane@example.com]", session.To)
+		}
+		for _, want := range []string{
+			"To: Jane <jane@example.com>",
+			"Subject: Hello",
+			"List-Unsubscribe: <http://example.com/unsubscribe>",
+			"plain body",
+			"<p>html body</p>",
+		} {
+			if !strings.Contains(session.Data, want) {
+				t.Errorf("the email doesn't contain %q", want)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server never received the email")
+	}
+}
+
+func TestSMTPMailerSendHonoursContext(t *testing.T) {
+	// The server accepts the connection but never greets the client.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen : %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(2 * time.Second)
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	m := &SMTPMailer{Host: host, Port: port}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = m.Send(ctx, "no-reply@example.com", Message{To: "jane@example.com", Text: "body"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Send = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Send returned after %v, want right after the deadline", elapsed)
+	}
+}
+
+func TestEnvelopeAddress(t *testing.T) {
+	tests := map[string]string{
+		"jane@example.com":               "jane@example.com",
+		"  jane@example.com ":            "jane@example.com",
+		"Jane <jane@example.com>":        "jane@example.com",
+		`"Doe, Jane" <jane@example.com>`: "jane@example.com",
+	}
+
+	for address, want := range tests {
+		if got := envelopeAddress(address); got != want {
+			t.Errorf("envelopeAddress(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+)
+
+const (
+	TemplateMatchRequested = "match_requested"
+	TemplateMatchApproved  = "match_approved"
+	TemplateMatchRejected  = "match_rejected"
+	TemplateVerifyEmail    = "verify_email"
+	TemplatePasswordReset  = "password_reset"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+var (
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+)
+
+var subjects = map[string]string{
+	TemplateMatchRequested: "{{.ActorName}} wants to match {{.IssuerCatName}} with {{.MatchCatName}}",
+	TemplateMatchApproved:  "{{.ActorName}} approved the match with {{.MatchCatName}}",
+	TemplateMatchRejected:  "{{.ActorName}} declined the match with {{.MatchCatName}}",
+	TemplateVerifyEmail:    "Verify your email address",
+	TemplatePasswordReset:  "Reset your password",
+}
+
+type TemplateData struct {
+	RecipientName  string
+	ActorName      string
+	IssuerCatName  string
+	MatchCatName   string
+	Message        string
+	ActionURL      string
+	UnsubscribeURL string
+	ExpiresIn      string
+}
+
+// Render builds the email of the template for the given recipient.
+func Render(name string, to string, data TemplateData) (Message, error) {
+	subject, err := texttemplate.New("subject").Parse(subjects[name])
+	if err != nil {
+		return Message{}, err
+	}
+
+	var subjectBuf, textBuf, htmlBuf bytes.Buffer
+
+	if err := subject.Execute(&subjectBuf, data); err != nil {
+		return Message{}, err
+	}
+
+	if err := textTemplates.ExecuteTemplate(&textBuf, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+
+	if err := htmlTemplates.ExecuteTemplate(&htmlBuf, name+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	msg := Message{
+		To:      to,
+		Subject: subjectBuf.String(),
+		Text:    textBuf.String(),
+		HTML:    htmlBuf.String(),
+	}
+
+	if data.UnsubscribeURL != "" {
+		msg.Headers = map[string]string{"List-Unsubscribe": "<" + data.UnsubscribeURL + ">"}
+	}
+
+	return msg, nil
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// APIKeyStore resolves the stored keys, implemented by the repositories.
+type APIKeyStore interface {
+	GetAPIKeyByHash(keyHash string) (models.APIKey, error)
+	TouchAPIKey(id uuid.UUID) error
+}
+
+// CredentialStore resolves both kinds of credentials.
+type CredentialStore interface {
+	APIKeyStore
+	TokenStore
+}
+
+// JWTOrAPIKey accepts a bearer JWT or an API key, from the X-API-Key header or
+// as the bearer token, and stores the same principal for both. API keys need
+// the given scope, signed in users have every scope.
+func JWTOrAPIKey(cfg config.JWT, keys *jwtkeys.KeySet, store CredentialStore, scope string) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		token := c.Get("X-API-Key")
+		if token == "" {
+			token = utils.ExtractBearerToken(c)
+		}
+
+		if token == "" {
+			return jwtError(c, fiber.NewError(fiber.StatusBadRequest, "Missing or malformed JWT"))
+		}
+
+		var principal *models.Principal
+		var err error
+
+		if utils.IsAPIKey(token) {
+			principal, err = apiKeyPrincipal(store, token)
+			if err != nil && !errors.Is(err, errInvalidAPIKey) {
+				log.Printf("Failed to get the API key : %+v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": true,
+					"msg":   fiber.ErrInternalServerError.Message,
+				})
+			}
+		} else {
+			principal, err = accessTokenPrincipal(cfg, keys, store, token)
+		}
+
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		if !principal.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "API key is missing the " + scope + " scope",
+			})
+		}
+
+		utils.SetPrincipal(c, principal)
+
+		return c.Next()
+	}
+}
+
+var errInvalidAPIKey = errors.New("invalid or revoked API key")
+
+func apiKeyPrincipal(keys APIKeyStore, token string) (*models.Principal, error) {
+	key, err := keys.GetAPIKeyByHash(utils.HashAPIKey(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, errInvalidAPIKey
+	}
+
+	if err := keys.TouchAPIKey(key.ID); err != nil {
+		log.Printf("Failed to update API key last use : %+v", err)
+	}
+
+	principal := &models.Principal{
+		UserID:     key.UserID,
+		TokenID:    key.ID.String(),
+		IssuedAt:   key.CreatedAt,
+		AuthMethod: models.AuthMethodAPIKey,
+		APIKeyID:   &key.ID,
+		Scopes:     key.Scopes,
+	}
+
+	if key.ExpiresAt != nil {
+		principal.ExpiresAt = *key.ExpiresAt
+	}
+
+	return principal, nil
+}
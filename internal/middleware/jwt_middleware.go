@@ -1,20 +1,85 @@
 package middleware
 
 import (
-	"os"
+	"database/sql"
+	"errors"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
-	jwtMiddleware "github.com/gofiber/contrib/jwt"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
 )
 
-func JWTProtected() func(*fiber.Ctx) error {
-	config := jwtMiddleware.Config{
-		SigningKey:   jwtMiddleware.SigningKey{Key: []byte(os.Getenv("JWT_SECRET_KEY"))},
-		ContextKey:   "jwt", // used in private routes
-		ErrorHandler: jwtError,
+// TokenStore tells whether the user of a token was signed out since it was
+// issued, implemented by the repositories.
+type TokenStore interface {
+	GetTokenStatus(userId uuid.UUID, sessionId *uuid.UUID) (models.TokenStatus, error)
+}
+
+// JWTProtected verifies the bearer token once, signature and standard claims,
+// and stores the principal in the request locals for the controllers. Tokens
+// of a deleted user, a revoked session or issued before a password change
+// are refused.
+func JWTProtected(cfg config.JWT, keys *jwtkeys.KeySet, tokens TokenStore) func(*fiber.Ctx) error {
+	return func(c *fiber.Ctx) error {
+		token := utils.ExtractBearerToken(c)
+		if token == "" {
+			return jwtError(c, fiber.NewError(fiber.StatusBadRequest, "Missing or malformed JWT"))
+		}
+
+		// Account endpoints, key management included, need a signed in user.
+		if utils.IsAPIKey(token) {
+			return jwtError(c, fiber.NewError(fiber.StatusUnauthorized, "API keys can't be used for this endpoint"))
+		}
+
+		principal, err := accessTokenPrincipal(cfg, keys, tokens, token)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		utils.SetPrincipal(c, principal)
+
+		return c.Next()
+	}
+}
+
+var errRevokedToken = errors.New("token was revoked, please sign in again")
+
+// accessTokenPrincipal parses the token and checks its user wasn't signed out
+// since. Errors other than an invalid or revoked token are logged and
+// reported as a server error.
+func accessTokenPrincipal(cfg config.JWT, keys *jwtkeys.KeySet, tokens TokenStore, token string) (*models.Principal, error) {
+	principal, err := utils.ParseAccessToken(cfg, keys, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessionId *uuid.UUID
+	if principal.SessionID != "" {
+		id, err := uuid.Parse(principal.SessionID)
+		if err != nil {
+			return nil, errRevokedToken
+		}
+		sessionId = &id
 	}
 
-	return jwtMiddleware.New(config)
+	status, err := tokens.GetTokenStatus(principal.UserID, sessionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errRevokedToken
+	}
+	if err != nil {
+		log.Printf("Failed to get the token status : %+v", err)
+		return nil, fiber.ErrInternalServerError
+	}
+
+	if status.IsRevoked(principal) {
+		return nil, errRevokedToken
+	}
+
+	return principal, nil
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
@@ -25,8 +90,15 @@ func jwtError(c *fiber.Ctx, err error) error {
 		})
 	}
 
+	if errors.Is(err, fiber.ErrInternalServerError) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": true,
+			"msg":   err.Error(),
+		})
+	}
+
 	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 		"error": true,
 		"msg":   err.Error(),
 	})
-}
\ No newline at end of file
+}
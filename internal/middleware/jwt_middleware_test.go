@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+var testJWTConfig = config.JWT{
+	Issuer:    "cat-socialx",
+	Audience:  "cat-socialx-api",
+	AccessTTL: time.Minute * 15,
+	ClockSkew: time.Second * 30,
+}
+
+// fakeTokenStore answers every lookup with the same status or error.
+type fakeTokenStore struct {
+	status models.TokenStatus
+	err    error
+}
+
+func (s *fakeTokenStore) GetTokenStatus(userId uuid.UUID, sessionId *uuid.UUID) (models.TokenStatus, error) {
+	return s.status, s.err
+}
+
+func protectedApp(keys *jwtkeys.KeySet, store TokenStore) *fiber.App {
+	app := fiber.New()
+	app.Get("/", JWTProtected(testJWTConfig, keys, store), func(c *fiber.Ctx) error {
+		principal, err := utils.CurrentPrincipal(c)
+		if err != nil {
+			return err
+		}
+
+		return c.SendString(principal.UserID.String())
+	})
+
+	return app
+}
+
+func TestJWTProtectedRefusesRevokedTokens(t *testing.T) {
+	keys, err := jwtkeys.Load("", "")
+	if err != nil {
+		t.Fatalf("jwtkeys.Load : %v", err)
+	}
+
+	tokens, err := utils.GenerateNewTokens(testJWTConfig, keys, uuid.NewString(), uuid.NewString())
+	if err != nil {
+		t.Fatalf("GenerateNewTokens : %v", err)
+	}
+
+	// Tokens issued before sessions existed have no sid.
+	withoutSession, err := utils.GenerateNewTokens(testJWTConfig, keys, uuid.NewString(), "")
+	if err != nil {
+		t.Fatalf("GenerateNewTokens : %v", err)
+	}
+
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		store  *fakeTokenStore
+		token  string
+		status int
+	}{
+		{"live session", &fakeTokenStore{status: models.TokenStatus{SessionFound: true}}, tokens.Access, fiber.StatusOK},
+		{"tokens revoked before the token was issued", &fakeTokenStore{status: models.TokenStatus{SessionFound: true, TokensRevokedAt: &past}}, tokens.Access, fiber.StatusOK},
+		{"revoked session", &fakeTokenStore{status: models.TokenStatus{SessionFound: true, SessionRevokedAt: &past}}, tokens.Access, fiber.StatusUnauthorized},
+		{"unknown session", &fakeTokenStore{status: models.TokenStatus{}}, tokens.Access, fiber.StatusUnauthorized},
+		{"token without session", &fakeTokenStore{status: models.TokenStatus{}}, withoutSession.Access, fiber.StatusOK},
+		{"tokens revoked after the token was issued", &fakeTokenStore{status: models.TokenStatus{SessionFound: true, TokensRevokedAt: &future}}, tokens.Access, fiber.StatusUnauthorized},
+		{"deleted user", &fakeTokenStore{status: models.TokenStatus{SessionFound: true, UserDeletedAt: &past}}, tokens.Access, fiber.StatusUnauthorized},
+		{"purged user", &fakeTokenStore{err: sql.ErrNoRows}, tokens.Access, fiber.StatusUnauthorized},
+		{"store error", &fakeTokenStore{err: errors.New("connection refused")}, tokens.Access, fiber.StatusInternalServerError},
+		{"API key", &fakeTokenStore{}, utils.APIKeyPrefix + "abcdef", fiber.StatusUnauthorized},
+		{"no token", &fakeTokenStore{}, "", fiber.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		if tt.token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+tt.token)
+		}
+
+		resp, err := protectedApp(keys, tt.store).Test(req)
+		if err != nil {
+			t.Fatalf("%s : app.Test : %v", tt.name, err)
+		}
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s : status = %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+)
+
+// PasswordResetLimiter limits the reset emails per address. Unknown addresses
+// are limited too, so the response never tells whether an account exists.
+func PasswordResetLimiter(max int) func(*fiber.Ctx) error {
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: time.Hour,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			body := struct {
+				Email string `json:"email"`
+			}{}
+			_ = c.BodyParser(&body)
+
+			return "password-reset:" + strings.ToLower(strings.TrimSpace(body.Email))
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error":   fiber.ErrTooManyRequests.Message,
+				"message": "too many password reset requests, please try again later",
+			})
+		},
+	})
+}
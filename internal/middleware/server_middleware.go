@@ -11,7 +11,7 @@ func FiberMiddleware(a *fiber.App) {
 	a.Use(
 		cors.New(cors.Config{
 			AllowOrigins: "*",
-			AllowMethods: "GET,POST,PUT,DELETE",
+			AllowMethods: "GET,POST,PUT,PATCH,DELETE",
 		}),
 		logger.New(),
 		recover.New(),
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeCatsRead    = "cats:read"
+	ScopeCatsWrite   = "cats:write"
+	ScopeMatchesRead = "matches:read"
+)
+
+// APIKey only keeps the SHA-256 of the key, the key itself is shown once when
+// it is created. Prefix is the start of the key so owners can tell keys apart.
+type APIKey struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	UserID     uuid.UUID  `db:"user_id" json:"-"`
+	Name       string     `db:"name" json:"name"`
+	Prefix     string     `db:"prefix" json:"prefix"`
+	KeyHash    string     `db:"key_hash" json:"-"`
+	Scopes     []string   `db:"scopes" json:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at" json:"expiresAt"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"lastUsedAt"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revokedAt"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+}
+
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+type NewAPIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,min=1,max=64"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=cats:read cats:write matches:read"`
+	// Keys without an expiry stay valid until they are revoked.
+	ExpiresInDays *int `json:"expiresInDays" validate:"omitnil,min=1,max=365"`
+}
+
+type NewAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
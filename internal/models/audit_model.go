@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditUserSignedUp       = "user.signed_up"
+	AuditUserLogin          = "user.login"
+	AuditUserLoginFailed    = "user.login_failed"
+	AuditPasswordChanged    = "user.password_changed"
+	AuditPasswordReset      = "user.password_reset"
+	AuditEmailChanged       = "user.email_changed"
+	AuditMFAEnabled         = "user.mfa_enabled"
+	AuditMFADisabled        = "user.mfa_disabled"
+	AuditSessionRevoked     = "user.session_revoked"
+	AuditUserDataExported   = "user.data_exported"
+	AuditUserDeleted        = "user.deleted"
+	AuditAPIKeyCreated      = "api_key.created"
+	AuditAPIKeyRevoked      = "api_key.revoked"
+	AuditCatDeleted         = "cat.deleted"
+	AuditMatchApproved      = "match.approved"
+	AuditMatchRejected      = "match.rejected"
+	AuditAdminAuditExported = "admin.audit_exported"
+	AuditAdminGranted       = "admin.granted"
+)
+
+const (
+	AuditTargetUser    = "user"
+	AuditTargetCat     = "cat"
+	AuditTargetMatch   = "match"
+	AuditTargetSession = "session"
+	AuditTargetAPIKey  = "api_key"
+)
+
+// AuditEvent records who did what. ActorID is empty for anonymous actions,
+// e.g. a sign in with an unknown email.
+type AuditEvent struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	ActorID    *uuid.UUID      `db:"actor_id" json:"actorId"`
+	Action     string          `db:"action" json:"action"`
+	TargetType string          `db:"target_type" json:"targetType"`
+	TargetID   string          `db:"target_id" json:"targetId"`
+	IP         string          `db:"ip" json:"ip"`
+	UserAgent  string          `db:"user_agent" json:"userAgent"`
+	Metadata   json.RawMessage `db:"metadata" json:"metadata"`
+	CreatedAt  time.Time       `db:"created_at" json:"createdAt"`
+}
+
+// AuditEventFilter narrows the admin query, empty fields match everything.
+type AuditEventFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   string
+	IP         string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
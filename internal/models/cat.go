@@ -19,6 +19,9 @@ type Cats struct {
 	CreatedAt   time.Time   `db:"created_at" json:"createdAt"`
 	UpdatedAt   *time.Time  `db:"updated_at" json:"-"`
 	DeletedAt   *time.Time  `db:"deleted_at" json:"-"`
+	SireID      *uuid.UUID  `db:"sire_id" json:"sireId"`
+	DamID       *uuid.UUID  `db:"dam_id" json:"damId"`
+	LitterID    *uuid.UUID  `db:"litter_id" json:"litterId"`
 }
 
 type CatUpdateRequest struct {
@@ -28,17 +31,23 @@ type CatUpdateRequest struct {
 	AgeInMonth  int        `db:"ageinmonth" json:"ageInMonth" validate:"required,min=1,max=120082"`
 	Description string     `db:"description" json:"description" validate:"required,min=1,max=200"`
 	ImageUrls   []string   `db:"imageurls" json:"imageUrls" validate:"required,min=1,dive,url"`
-	UpdatedAt   *time.Time `db:"updated_at" json:"-"`
+	SireID      *uuid.UUID `db:"sire_id" json:"sireId" validate:"omitempty"`
+	DamID       *uuid.UUID `db:"dam_id" json:"damId" validate:"omitempty"`
+	// Omitted parents are kept, ClearSire and ClearDam remove them.
+	ClearSire bool       `db:"-" json:"clearSire"`
+	ClearDam  bool       `db:"-" json:"clearDam"`
+	UpdatedAt *time.Time `db:"updated_at" json:"-"`
 }
 
 type Cat struct {
 	ID     uuid.UUID `json:"id" db:"id"`
 	UserID uuid.UUID `json:"user_id" db:"user_id"`
 	NewCat
-	HasMatched bool      `json:"hasMatched" db:"hasmatched"`
-	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
-	UpdatedAt  time.Time `json:"-" db:"updated_at"`
-	DeletedAt  time.Time `json:"-" db:"deleted_at"`
+	HasMatched bool       `json:"hasMatched" db:"hasmatched"`
+	LitterID   *uuid.UUID `json:"litterId" db:"litter_id"`
+	CreatedAt  time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time  `json:"-" db:"updated_at"`
+	DeletedAt  time.Time  `json:"-" db:"deleted_at"`
 }
 
 type NewCat struct {
@@ -48,6 +57,8 @@ type NewCat struct {
 	AgeInMonth  int      `json:"ageInMonth" db:"ageinmonth" validate:"required,min=1,max=120082"`
 	Description string   `json:"description" db:"description" validate:"required,min=1,max=200"`
 	ImageUrls   []string `json:"imageUrls" db:"imageurls" validate:"required,min=1,dive,url"`
+	SireID      *uuid.UUID `json:"sireId" db:"sire_id" validate:"omitempty"`
+	DamID       *uuid.UUID `json:"damId" db:"dam_id" validate:"omitempty"`
 }
 
 type CatData struct {
@@ -59,5 +70,9 @@ type CatData struct {
 	ImageUrls   []string `json:"imageUrls"`
 	Description string   `json:"description"`
 	HasMatched  bool     `json:"hasMatched"`
+	SireID      *string  `json:"sireId"`
+	DamID       *string  `json:"damId"`
+	LitterID    *string  `json:"litterId"`
 	CreatedAt   string   `json:"createdAt"`
+	Litters     []Litter `json:"litters,omitempty"`
 }
\ No newline at end of file
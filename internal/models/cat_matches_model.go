@@ -15,6 +15,9 @@ type CatMatch struct {
 	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt   *time.Time `db:"updated_at" json:"-"`
 	DeletedAt   *time.Time `db:"deleted_at" json:"-"`
+
+	InbreedingCoefficient float64 `db:"-" json:"inbreedingCoefficient"`
+	InbreedingWarning     string  `db:"-" json:"inbreedingWarning,omitempty"`
 }
 
 type CatMatchRequest struct {
@@ -25,6 +28,8 @@ type CatMatchRequest struct {
 
 type CatMatchUpdateRequest struct {
 	ID uuid.UUID `db:"id" json:"matchId" validate:"required,uuid"`
+	// Only used on approval, both cats need up-to-date vaccinations.
+	RequireVaccinations bool `db:"-" json:"requireVaccinations"`
 }
 
 type CatMatchDetail struct {
@@ -37,6 +42,9 @@ type CatMatchDetail struct {
 	CreatedAt      time.Time  `db:"created_at" json:"createdAt"`
 	UpdatedAt      *time.Time `db:"updated_at" json:"-"`
 	DeletedAt      *time.Time `db:"deleted_at" json:"-"`
+
+	InbreedingCoefficient float64 `db:"-" json:"inbreedingCoefficient"`
+	InbreedingWarning     string  `db:"-" json:"inbreedingWarning,omitempty"`
 }
 
 type IssuerUser struct {
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ChatMessage struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	CatMatchID uuid.UUID  `db:"cat_match_id" json:"matchId"`
+	SenderID   uuid.UUID  `db:"sender_id" json:"senderId"`
+	Body       string     `db:"body" json:"body"`
+	ReadAt     *time.Time `db:"read_at" json:"readAt"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+}
+
+type ChatMessageRequest struct {
+	Body string `json:"body" validate:"required,min=1,max=2000"`
+}
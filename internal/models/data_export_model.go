@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExportCat is a cat in the personal data export, deleted cats included.
+type ExportCat struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Race        string     `db:"race" json:"race"`
+	Sex         string     `db:"sex" json:"sex"`
+	AgeInMonth  int        `db:"ageinmonth" json:"ageInMonth"`
+	Description string     `db:"description" json:"description"`
+	HasMatched  bool       `db:"hasmatched" json:"hasMatched"`
+	ImageUrls   []string   `db:"-" json:"imageUrls"`
+	SireID      *uuid.UUID `db:"sire_id" json:"sireId"`
+	DamID       *uuid.UUID `db:"dam_id" json:"damId"`
+	LitterID    *uuid.UUID `db:"litter_id" json:"litterId"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   *time.Time `db:"updated_at" json:"updatedAt"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// ExportMatch is sent when the user's cat issued the request and received
+// otherwise.
+type ExportMatch struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Direction   string     `db:"direction" json:"direction"`
+	CatIssuerID uuid.UUID  `db:"cat_issuer_id" json:"userCatId"`
+	CatMatchID  uuid.UUID  `db:"cat_match_id" json:"matchCatId"`
+	Message     string     `db:"message" json:"message"`
+	Status      string     `db:"status" json:"status"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt   *time.Time `db:"updated_at" json:"updatedAt"`
+	DeletedAt   *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// ExportMessage covers the conversations of the user's matches, the messages
+// the other side deleted are left out.
+type ExportMessage struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	CatMatchID uuid.UUID  `db:"cat_match_id" json:"matchId"`
+	SenderID   uuid.UUID  `db:"sender_id" json:"senderId"`
+	Sent       bool       `db:"sent" json:"sent"`
+	Body       string     `db:"body" json:"body"`
+	ReadAt     *time.Time `db:"read_at" json:"readAt"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+	DeletedAt  *time.Time `db:"deleted_at" json:"deletedAt"`
+}
+
+// WithdrawnMatch is a pending match withdrawn by an account deletion,
+// RecipientID owns the cat on the other side.
+type WithdrawnMatch struct {
+	CatMatch
+	RecipientID uuid.UUID `db:"recipient_id"`
+}
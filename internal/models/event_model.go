@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventMatchRequested   = "match.requested"
+	EventMatchApproved    = "match.approved"
+	EventMatchRejected    = "match.rejected"
+	EventMatchWithdrawn   = "match.withdrawn"
+	EventLitterRegistered = "litter.registered"
+	EventChatMessage      = "chat.message"
+)
+
+// NotificationTypes are the event types stored in the notification inbox,
+// each one can be muted from the user preferences.
+var NotificationTypes = []string{
+	EventMatchRequested,
+	EventMatchApproved,
+	EventMatchRejected,
+	EventMatchWithdrawn,
+	EventLitterRegistered,
+	EventChatMessage,
+}
+
+// Email preferences are stored next to the in-app ones with this prefix.
+const EmailPreferencePrefix = "email."
+
+var EmailNotificationTypes = []string{
+	EmailPreferencePrefix + EventMatchRequested,
+	EmailPreferencePrefix + EventMatchApproved,
+	EmailPreferencePrefix + EventMatchRejected,
+}
+
+type Event struct {
+	Type        string      `json:"type"`
+	RecipientID uuid.UUID   `json:"recipientId"`
+	ActorID     uuid.UUID   `json:"actorId"`
+	Payload     interface{} `json:"payload"`
+	CreatedAt   time.Time   `json:"createdAt"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type HealthRecord struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	CatID        uuid.UUID  `db:"cat_id" json:"catId"`
+	RecordType   string     `db:"record_type" json:"recordType"`
+	Title        string     `db:"title" json:"title"`
+	Notes        string     `db:"notes" json:"notes"`
+	PerformedAt  time.Time  `db:"performed_at" json:"performedAt"`
+	ExpiresAt    *time.Time `db:"expires_at" json:"expiresAt"`
+	DocumentUrls []string   `db:"document_urls" json:"documentUrls"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+	UpdatedAt    *time.Time `db:"updated_at" json:"-"`
+	DeletedAt    *time.Time `db:"deleted_at" json:"-"`
+}
+
+type HealthRecordRequest struct {
+	RecordType   string   `json:"recordType" validate:"required,oneof=vaccination vet_check test_result"`
+	Title        string   `json:"title" validate:"required,min=1,max=100"`
+	Notes        string   `json:"notes" validate:"max=500"`
+	PerformedAt  string   `json:"performedAt" validate:"required,datetime=2006-01-02"`
+	ExpiresAt    string   `json:"expiresAt" validate:"omitempty,datetime=2006-01-02"`
+	DocumentUrls []string `json:"documentUrls" validate:"omitempty,max=10,dive,url"`
+}
+
+type SharedHealthRecords struct {
+	CatID   uuid.UUID      `json:"catId"`
+	Shared  bool           `json:"shared"`
+	Records []HealthRecord `json:"records"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Litter struct {
+	ID           uuid.UUID `db:"id" json:"id"`
+	CatMatchID   uuid.UUID `db:"cat_match_id" json:"matchId"`
+	SireID       uuid.UUID `db:"sire_id" json:"sireId"`
+	DamID        uuid.UUID `db:"dam_id" json:"damId"`
+	RegisteredBy uuid.UUID `db:"registered_by" json:"registeredBy"`
+	BornAt       time.Time `db:"born_at" json:"bornAt"`
+	CreatedAt    time.Time `db:"created_at" json:"createdAt"`
+	Kittens      []Kitten  `db:"-" json:"kittens"`
+}
+
+type Kitten struct {
+	ID       uuid.UUID `db:"id" json:"id"`
+	UserID   uuid.UUID `db:"user_id" json:"ownerId"`
+	LitterID uuid.UUID `db:"litter_id" json:"-"`
+	Name     string    `db:"name" json:"name"`
+	Sex      string    `db:"sex" json:"sex"`
+}
+
+type LitterRequest struct {
+	BornAt  string          `json:"bornAt" validate:"required,datetime=2006-01-02"`
+	Kittens []KittenRequest `json:"kittens" validate:"required,min=1,max=20,dive"`
+}
+
+type KittenRequest struct {
+	NewCat
+	OwnerID *uuid.UUID `json:"ownerId" validate:"omitempty"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt counts the recent failed logins of one key, an account email or
+// a client IP.
+type LoginAttempt struct {
+	Key           string     `db:"key" json:"key"`
+	Failures      int        `db:"failures" json:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at" json:"lastFailureAt"`
+	LockedUntil   *time.Time `db:"locked_until" json:"lockedUntil"`
+}
+
+// FailedLogin is the audit record of a rejected sign in.
+type FailedLogin struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Email     string     `db:"email" json:"email"`
+	UserID    *uuid.UUID `db:"user_id" json:"userId"`
+	IP        string     `db:"ip" json:"ip"`
+	UserAgent string     `db:"user_agent" json:"userAgent"`
+	Reason    string     `db:"reason" json:"reason"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+}
+
+const (
+	LoginFailureUnknownEmail  = "unknown_email"
+	LoginFailureWrongPassword = "wrong_password"
+	LoginFailureWrongMFACode  = "wrong_mfa_code"
+	LoginFailureThrottled     = "throttled"
+	// The guard couldn't read the counters, the sign in was refused.
+	LoginFailureGuardUnavailable = "guard_unavailable"
+)
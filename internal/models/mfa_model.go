@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA is the TOTP enrollment of a user. The secret is stored encrypted
+// and the enrollment is pending until the first code is verified.
+type UserMFA struct {
+	UserID       uuid.UUID  `db:"user_id" json:"-"`
+	Secret       string     `db:"secret" json:"-"`
+	EnabledAt    *time.Time `db:"enabled_at" json:"enabledAt"`
+	LastUsedStep int64      `db:"last_used_step" json:"-"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+}
+
+func (m *UserMFA) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
+
+type TOTPEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURL string `json:"otpauthUrl"`
+}
+
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabledAt"`
+	RemainingRecoveryCodes int        `json:"remainingRecoveryCodes"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required,numeric,len=6"`
+}
+
+type DisableMFARequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required_without=RecoveryCode"`
+	// A recovery code can replace the TOTP code, e.g. after losing the phone.
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+}
+
+type MFASignInRequest struct {
+	MFAToken     string `json:"mfaToken" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recoveryCode" validate:"required_without=Code"`
+}
+
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	ID        uuid.UUID       `db:"id" json:"id"`
+	UserID    uuid.UUID       `db:"user_id" json:"-"`
+	Type      string          `db:"type" json:"type"`
+	ActorID   *uuid.UUID      `db:"actor_id" json:"actorId"`
+	Payload   json.RawMessage `db:"payload" json:"payload"`
+	ReadAt    *time.Time      `db:"read_at" json:"readAt"`
+	CreatedAt time.Time       `db:"created_at" json:"createdAt"`
+}
+
+type NotificationPreference struct {
+	Type    string `db:"type" json:"type"`
+	Enabled bool   `db:"enabled" json:"enabled"`
+}
+
+type NotificationPreferencesRequest struct {
+	Preferences map[string]bool `json:"preferences" validate:"required,min=1"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links an account of an identity provider, by its subject, to
+// a user.
+type UserIdentity struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	UserID      uuid.UUID  `db:"user_id" json:"-"`
+	Provider    string     `db:"provider" json:"provider"`
+	Subject     string     `db:"subject" json:"-"`
+	Email       string     `db:"email" json:"email"`
+	LastLoginAt *time.Time `db:"last_login_at" json:"lastLoginAt"`
+	CreatedAt   time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// OIDCLoginState is a pending authorization request, kept server side so the
+// PKCE verifier never leaves the API. UserID is set when a signed in user
+// links a provider.
+type OIDCLoginState struct {
+	StateHash    string     `db:"state_hash"`
+	Provider     string     `db:"provider"`
+	CodeVerifier string     `db:"code_verifier"`
+	Nonce        string     `db:"nonce"`
+	UserID       *uuid.UUID `db:"user_id"`
+	ExpiresAt    time.Time  `db:"expires_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordResetToken only keeps the SHA-256 of the token sent by email.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	UserID    uuid.UUID  `db:"user_id" json:"userId"`
+	TokenHash string     `db:"token_hash" json:"-"`
+	ExpiresAt time.Time  `db:"expires_at" json:"expiresAt"`
+	UsedAt    *time.Time `db:"used_at" json:"usedAt"`
+	CreatedAt time.Time  `db:"created_at" json:"createdAt"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,lte=255"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
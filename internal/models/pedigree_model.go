@@ -0,0 +1,28 @@
+package models
+
+import "github.com/google/uuid"
+
+type CatAncestor struct {
+	ID         uuid.UUID  `db:"id" json:"id"`
+	Name       string     `db:"name" json:"name"`
+	Race       string     `db:"race" json:"race"`
+	Sex        string     `db:"sex" json:"sex"`
+	SireID     *uuid.UUID `db:"sire_id" json:"sireId"`
+	DamID      *uuid.UUID `db:"dam_id" json:"damId"`
+	Generation int        `db:"generation" json:"generation"`
+}
+
+type PedigreeNode struct {
+	ID   uuid.UUID     `json:"id"`
+	Name string        `json:"name"`
+	Race string        `json:"race"`
+	Sex  string        `json:"sex"`
+	Sire *PedigreeNode `json:"sire"`
+	Dam  *PedigreeNode `json:"dam"`
+}
+
+type PedigreeResponse struct {
+	Generations           int           `json:"generations"`
+	InbreedingCoefficient float64       `json:"inbreedingCoefficient"`
+	Pedigree              *PedigreeNode `json:"pedigree"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+// Principal is the authenticated caller, verified once by the middleware and
+// read by the controllers from the request locals.
+type Principal struct {
+	UserID    uuid.UUID
+	TokenID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	// SessionID is the device session of a signed in user, empty for API keys.
+	SessionID string
+	// AuthMethod tells whether the caller signed in or used an API key. Only
+	// API keys are restricted to their scopes.
+	AuthMethod string
+	APIKeyID   *uuid.UUID
+	Scopes     []string
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	if p.AuthMethod != AuthMethodAPIKey {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a signed in device. It only keeps the SHA-256 of its refresh
+// token, which is replaced on every renewal.
+type Session struct {
+	ID               uuid.UUID  `db:"id" json:"id"`
+	UserID           uuid.UUID  `db:"user_id" json:"-"`
+	RefreshTokenHash string     `db:"refresh_token_hash" json:"-"`
+	DeviceName       string     `db:"device_name" json:"deviceName"`
+	UserAgent        string     `db:"user_agent" json:"userAgent"`
+	IP               string     `db:"ip" json:"ip"`
+	CreatedAt        time.Time  `db:"created_at" json:"createdAt"`
+	LastSeenAt       time.Time  `db:"last_seen_at" json:"lastSeenAt"`
+	ExpiresAt        time.Time  `db:"expires_at" json:"expiresAt"`
+	RevokedAt        *time.Time `db:"revoked_at" json:"-"`
+}
+
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// TokenStatus tells whether the user of an access token was signed out since
+// the token was issued, it is read on every signed in request.
+type TokenStatus struct {
+	UserDeletedAt    *time.Time `db:"user_deleted_at"`
+	TokensRevokedAt  *time.Time `db:"tokens_revoked_at"`
+	SessionFound     bool       `db:"session_found"`
+	SessionRevokedAt *time.Time `db:"session_revoked_at"`
+}
+
+// IsRevoked tells whether the token of the principal can't be used anymore,
+// after the account deletion, a password change or the end of its session.
+func (s *TokenStatus) IsRevoked(p *Principal) bool {
+	if s.UserDeletedAt != nil {
+		return true
+	}
+
+	if s.TokensRevokedAt != nil && p.IssuedAt.Unix() < s.TokensRevokedAt.Unix() {
+		return true
+	}
+
+	return p.SessionID != "" && (!s.SessionFound || s.SessionRevokedAt != nil)
+}
+
+type SessionResponse struct {
+	Session
+	Current bool `json:"current"`
+}
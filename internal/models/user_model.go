@@ -6,31 +6,110 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 type User struct {
 	ID          uuid.UUID `db:"id" json:"id" validate:"required,uuid"`
 	Name        string    `db:"name" json:"name" validate:"required,min=5,max=50"`
 	Email       string    `db:"email" json:"email" validate:"required,email,lte=255"`
-	Password    string    `db:"password" json:"password,omitempty" validate:"required,min=5,max=15"`
+	Password    string    `db:"password" json:"password,omitempty" validate:"required"`
 	UserStatus  int       `db:"user_status" json:"-" validate:"required,len=1"`
 	UserRole    string    `db:"user_role" json:"-" validate:"required"`
 	AccessToken string    `db:"-" json:"accessToken"`
 	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
 	UpdatedAt   time.Time `db:"updated_at" json:"-"`
+
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"emailVerifiedAt"`
+	TokensRevokedAt *time.Time `db:"tokens_revoked_at" json:"-"`
+
+	Bio       string `db:"bio" json:"bio"`
+	AvatarURL string `db:"avatar_url" json:"avatarUrl"`
+
+	DeletedAt  *time.Time `db:"deleted_at" json:"-"`
+	PurgeAfter *time.Time `db:"purge_after" json:"-"`
+	PurgedAt   *time.Time `db:"purged_at" json:"-"`
+}
+
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 type SignUpRequest struct {
 	Email    string `db:"email" json:"email" validate:"required,email,lte=255"`
 	Name     string `db:"name" json:"name" validate:"required,min=5,max=50"`
-	Password string `db:"password" json:"password,omitempty" validate:"required,min=5,max=15"`
+	Password string `db:"password" json:"password,omitempty" validate:"required"`
 }
 
 type AuthResponse struct {
 	Email       string `db:"email" json:"email" validate:"required,email,lte=255"`
 	Name        string `db:"name" json:"name" validate:"required,min=5,max=50"`
 	AccessToken string `db:"token" json:"accessToken"`
+
+	RefreshToken  string `db:"-" json:"refreshToken"`
+	EmailVerified bool   `db:"-" json:"emailVerified"`
 }
 
 type SignInRequest struct {
 	Email    string `db:"email" json:"email" validate:"required,email,lte=255"`
-	Password string `db:"password" json:"password,omitempty" validate:"required,min=5,max=15"`
+	Password string `db:"password" json:"password,omitempty" validate:"required"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UserProfile struct {
+	ID            uuid.UUID  `json:"id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name"`
+	Bio           string     `json:"bio"`
+	AvatarURL     string     `json:"avatarUrl"`
+	EmailVerified bool       `json:"emailVerified"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     *time.Time `json:"updatedAt"`
+}
+
+func (u *User) Profile() UserProfile {
+	profile := UserProfile{
+		ID:            u.ID,
+		Email:         u.Email,
+		Name:          u.Name,
+		Bio:           u.Bio,
+		AvatarURL:     u.AvatarURL,
+		EmailVerified: u.IsEmailVerified(),
+		CreatedAt:     u.CreatedAt,
+	}
+
+	// Accounts that were never updated store the zero time.
+	if !u.UpdatedAt.IsZero() {
+		profile.UpdatedAt = &u.UpdatedAt
+	}
+
+	return profile
+}
+
+// UpdateProfileRequest only changes the fields present in the payload, an
+// empty bio or avatar clears it.
+type UpdateProfileRequest struct {
+	Name      *string `json:"name" validate:"omitnil,min=5,max=50"`
+	Bio       *string `json:"bio" validate:"omitnil,max=500"`
+	AvatarURL *string `json:"avatarUrl" validate:"omitnil,max=2048,url|len=0"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required"`
+}
+
+// DeleteAccountRequest confirms the deletion with the current password.
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangeEmailRequest struct {
+	Email    string `json:"email" validate:"required,email,lte=255"`
+	Password string `json:"password" validate:"required"`
 }
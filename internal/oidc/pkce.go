@@ -0,0 +1,33 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// RandomString returns n random bytes, base64url encoded. 32 bytes give a 43
+// characters PKCE verifier (RFC 7636 4.1).
+func RandomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge is the S256 challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// HashState is what is stored of the state, like the other single-use tokens.
+func HashState(state string) string {
+	sum := sha256.Sum256([]byte(state))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,257 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Config describes one identity provider. Only the issuer is needed, the
+// endpoints are read from its discovery document.
+type Config struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Discovery is the part of the OpenID Provider Metadata the flow uses.
+type Discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims are the ID token claims used to find or create the user.
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce         string      `json:"nonce"`
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+}
+
+// IsEmailVerified accepts both a boolean and the "true" string some
+// providers send.
+func (c *Claims) IsEmailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return strings.EqualFold(v, "true")
+	}
+
+	return false
+}
+
+var ErrInvalidIDToken = errors.New("invalid ID token")
+
+// Provider runs the authorization code flow against one issuer. Discovery and
+// keys are fetched on first use and cached.
+type Provider struct {
+	Config
+
+	client *http.Client
+	leeway time.Duration
+
+	mu        sync.Mutex
+	discovery *Discovery
+	keys      *keyCache
+}
+
+func NewProvider(cfg Config) *Provider {
+	return &Provider{
+		Config: cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		leeway: time.Minute,
+	}
+}
+
+func (p *Provider) Discover(ctx context.Context) (*Discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+
+	d := &Discovery{}
+	if err := p.getJSON(ctx, wellKnown, d); err != nil {
+		return nil, fmt.Errorf("discovery of %s: %w", p.Name, err)
+	}
+
+	// The ID tokens have to come from the configured issuer (OIDC Discovery 4.3).
+	if strings.TrimSuffix(d.Issuer, "/") != strings.TrimSuffix(p.Issuer, "/") {
+		return nil, fmt.Errorf("discovery of %s: issuer %q doesn't match %q", p.Name, d.Issuer, p.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery of %s: missing endpoints", p.Name)
+	}
+
+	p.discovery = d
+	p.keys = newKeyCache(d.JWKSURI, p.getJSON)
+
+	return d, nil
+}
+
+// AuthCodeURL is where the user is sent to sign in, with the S256 challenge of
+// the PKCE verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.ClientID)
+	params.Set("redirect_uri", p.RedirectURL)
+	params.Set("scope", strings.Join(p.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", CodeChallenge(verifier))
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// Exchange trades the authorization code for the tokens.
+func (p *Provider) Exchange(ctx context.Context, code string, verifier string) (*TokenResponse, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.RedirectURL)
+	form.Set("client_id", p.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint of %s returned %d: %s", p.Name, res.StatusCode, body)
+	}
+
+	tokens := &TokenResponse{}
+	if err := json.Unmarshal(body, tokens); err != nil {
+		return nil, err
+	}
+
+	if tokens.IDToken == "" {
+		return nil, fmt.Errorf("token endpoint of %s returned no ID token", p.Name)
+	}
+
+	return tokens, nil
+}
+
+// VerifyIDToken checks the signature, issuer, audience, lifetime and nonce of
+// the ID token.
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	d, err := p.Discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+
+	_, err = jwt.ParseWithClaims(
+		rawIDToken,
+		claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.keys.key(ctx, kid)
+		},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}),
+		jwt.WithLeeway(p.leeway),
+		jwt.WithIssuer(d.Issuer),
+		jwt.WithAudience(p.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned %d", u, res.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,471 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "cat-socialx"
+	testClientSecret = "client-secret"
+	testRedirectURL  = "http://127.0.0.1:5000/v1/user/oidc/mock/callback"
+)
+
+// fakeProvider is an identity provider serving the discovery document, the
+// signing keys and the token endpoint. The token endpoint checks the PKCE
+// verifier against the challenge of the authorization request.
+type fakeProvider struct {
+	server *httptest.Server
+
+	mu sync.Mutex
+	// issuer is the issuer of the discovery document, the server URL unless
+	// a test overrides it.
+	issuer     string
+	noJWKS     bool
+	keys       []jwk
+	challenges map[string]string
+	idTokens   map[string]string
+	tokenForms []url.Values
+	basicAuth  [2]string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+
+	fp := &fakeProvider{
+		challenges: map[string]string{},
+		idTokens:   map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", fp.discovery)
+	mux.HandleFunc("/jwks", fp.jwks)
+	mux.HandleFunc("/token", fp.token)
+
+	fp.server = httptest.NewServer(mux)
+	fp.issuer = fp.server.URL
+	t.Cleanup(fp.server.Close)
+
+	return fp
+}
+
+func (fp *fakeProvider) discovery(w http.ResponseWriter, r *http.Request) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	d := Discovery{
+		Issuer:                fp.issuer,
+		AuthorizationEndpoint: fp.server.URL + "/authorize",
+		TokenEndpoint:         fp.server.URL + "/token",
+	}
+	if !fp.noJWKS {
+		d.JWKSURI = fp.server.URL + "/jwks"
+	}
+
+	json.NewEncoder(w).Encode(d)
+}
+
+func (fp *fakeProvider) jwks(w http.ResponseWriter, r *http.Request) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	json.NewEncoder(w).Encode(jwkSet{Keys: fp.keys})
+}
+
+func (fp *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fp.tokenForms = append(fp.tokenForms, r.PostForm)
+	fp.basicAuth[0], fp.basicAuth[1], _ = r.BasicAuth()
+
+	code := r.PostForm.Get("code")
+	challenge, ok := fp.challenges[code]
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	if CodeChallenge(r.PostForm.Get("code_verifier")) != challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant","error_description":"PKCE verification failed"}`))
+		return
+	}
+
+	// A code is only good once.
+	delete(fp.challenges, code)
+
+	json.NewEncoder(w).Encode(TokenResponse{
+		AccessToken: "access-token",
+		TokenType:   "Bearer",
+		IDToken:     fp.idTokens[code],
+		ExpiresIn:   3600,
+	})
+}
+
+// authorize plays the user signing in at the provider, the code is exchanged
+// for the ID token.
+func (fp *fakeProvider) authorize(code string, challenge string, idToken string) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	fp.challenges[code] = challenge
+	fp.idTokens[code] = idToken
+}
+
+func (fp *fakeProvider) publishRSA(kid string, key *rsa.PublicKey) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	fp.keys = append(fp.keys, jwk{
+		KeyType: "RSA",
+		KeyID:   kid,
+		Use:     "sig",
+		N:       base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	})
+}
+
+func (fp *fakeProvider) publishEC(kid string, key *ecdsa.PublicKey) {
+	fp.mu.Lock()
+	defer fp.mu.Unlock()
+
+	fp.keys = append(fp.keys, jwk{
+		KeyType: "EC",
+		KeyID:   kid,
+		Use:     "sig",
+		Curve:   "P-256",
+		X:       base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		Y:       base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+	})
+}
+
+func (fp *fakeProvider) provider() *Provider {
+	return NewProvider(Config{
+		Name:         "mock",
+		Issuer:       fp.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key : %v", err)
+	}
+
+	return key
+}
+
+func validClaims(issuer string, nonce string) *Claims {
+	now := time.Now()
+
+	return &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   "subject-1",
+			Audience:  jwt.ClaimStrings{testClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		Nonce:         nonce,
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	}
+}
+
+func signIDToken(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims *Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign ID token : %v", err)
+	}
+
+	return signed
+}
+
+func TestProviderAuthorizationCodeFlow(t *testing.T) {
+	fp := newFakeProvider(t)
+	key := newRSAKey(t)
+	fp.publishRSA("rsa-1", &key.PublicKey)
+
+	p := fp.provider()
+	ctx := context.Background()
+
+	verifier, _ := RandomString(32)
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL : %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse %q : %v", authURL, err)
+	}
+
+	query := parsed.Query()
+	for param, want := range map[string]string{
+		"response_type":         "code",
+		"client_id":             testClientID,
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "state-1",
+		"nonce":                 "nonce-1",
+		"code_challenge":        CodeChallenge(verifier),
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(param); got != want {
+			t.Errorf("%s = %q, want %q", param, got, want)
+		}
+	}
+	if !strings.HasPrefix(authURL, fp.server.URL+"/authorize?") {
+		t.Errorf("authorization URL %q isn't on the provider endpoint", authURL)
+	}
+
+	fp.authorize("code-1", query.Get("code_challenge"), signIDToken(t, jwt.SigningMethodRS256, "rsa-1", key, validClaims(fp.issuer, "nonce-1")))
+
+	tokens, err := p.Exchange(ctx, "code-1", verifier)
+	if err != nil {
+		t.Fatalf("Exchange : %v", err)
+	}
+
+	form := fp.tokenForms[0]
+	if form.Get("redirect_uri") != testRedirectURL || form.Get("client_id") != testClientID {
+		t.Errorf("token request = %v, want the redirect URL and client ID", form)
+	}
+	if fp.basicAuth != [2]string{testClientID, testClientSecret} {
+		t.Errorf("basic auth = %v, want the client credentials", fp.basicAuth)
+	}
+
+	claims, err := p.VerifyIDToken(ctx, tokens.IDToken, "nonce-1")
+	if err != nil {
+		t.Fatalf("VerifyIDToken : %v", err)
+	}
+
+	if claims.Subject != "subject-1" || claims.Email != "jane@example.com" || !claims.IsEmailVerified() {
+		t.Errorf("claims = %+v, want the subject and verified email of the token", claims)
+	}
+}
+
+func TestExchangeRejectsPKCEVerifierMismatch(t *testing.T) {
+	fp := newFakeProvider(t)
+	p := fp.provider()
+	ctx := context.Background()
+
+	verifier, _ := RandomString(32)
+	fp.authorize("code-1", CodeChallenge(verifier), "id-token")
+
+	other, _ := RandomString(32)
+	if _, err := p.Exchange(ctx, "code-1", other); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("Exchange with another verifier = %v, want the provider to reject it", err)
+	}
+
+	if _, err := p.Exchange(ctx, "code-1", verifier); err != nil {
+		t.Fatalf("Exchange with the right verifier : %v", err)
+	}
+
+	// The code was consumed by the successful exchange.
+	if _, err := p.Exchange(ctx, "code-1", verifier); err == nil {
+		t.Fatal("Exchange accepted a used code")
+	}
+}
+
+func TestExchangeRequiresIDToken(t *testing.T) {
+	fp := newFakeProvider(t)
+	p := fp.provider()
+
+	verifier, _ := RandomString(32)
+	fp.authorize("code-1", CodeChallenge(verifier), "")
+
+	if _, err := p.Exchange(context.Background(), "code-1", verifier); err == nil {
+		t.Fatal("Exchange accepted a response without an ID token")
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidTokens(t *testing.T) {
+	fp := newFakeProvider(t)
+	key := newRSAKey(t)
+	fp.publishRSA("rsa-1", &key.PublicKey)
+	forger := newRSAKey(t)
+
+	tests := []struct {
+		name   string
+		nonce  string
+		key    *rsa.PrivateKey
+		kid    string
+		modify func(c *Claims)
+	}{
+		{name: "nonce mismatch", nonce: "another-nonce"},
+		{name: "other issuer", modify: func(c *Claims) { c.Issuer = "https://evil.example.com" }},
+		{name: "other audience", modify: func(c *Claims) { c.Audience = jwt.ClaimStrings{"another-client"} }},
+		{name: "expired", modify: func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }},
+		{name: "no expiry", modify: func(c *Claims) { c.ExpiresAt = nil }},
+		{name: "issued in the future", modify: func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) }},
+		{name: "missing subject", modify: func(c *Claims) { c.Subject = "" }},
+		{name: "forged signature", key: forger},
+		{name: "unknown key", kid: "rsa-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := fp.provider()
+
+			claims := validClaims(fp.issuer, "nonce-1")
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+
+			signingKey, kid := key, "rsa-1"
+			if tt.key != nil {
+				signingKey = tt.key
+			}
+			if tt.kid != "" {
+				kid = tt.kid
+			}
+
+			nonce := "nonce-1"
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			raw := signIDToken(t, jwt.SigningMethodRS256, kid, signingKey, claims)
+			if _, err := p.VerifyIDToken(context.Background(), raw, nonce); !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("VerifyIDToken = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsHMAC(t *testing.T) {
+	fp := newFakeProvider(t)
+	key := newRSAKey(t)
+	fp.publishRSA("rsa-1", &key.PublicKey)
+
+	// An HS256 token keyed with the public key must not pass as RS256.
+	raw := signIDToken(t, jwt.SigningMethodHS256, "rsa-1", key.PublicKey.N.Bytes(), validClaims(fp.issuer, "nonce-1"))
+
+	if _, err := fp.provider().VerifyIDToken(context.Background(), raw, "nonce-1"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestVerifyIDTokenWithECKey(t *testing.T) {
+	fp := newFakeProvider(t)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate EC key : %v", err)
+	}
+	fp.publishEC("ec-1", &key.PublicKey)
+
+	raw := signIDToken(t, jwt.SigningMethodES256, "ec-1", key, validClaims(fp.issuer, "nonce-1"))
+
+	if _, err := fp.provider().VerifyIDToken(context.Background(), raw, "nonce-1"); err != nil {
+		t.Fatalf("VerifyIDToken : %v", err)
+	}
+}
+
+func TestVerifyIDTokenPicksUpRotatedKeys(t *testing.T) {
+	fp := newFakeProvider(t)
+	oldKey := newRSAKey(t)
+	fp.publishRSA("rsa-1", &oldKey.PublicKey)
+
+	p := fp.provider()
+	ctx := context.Background()
+
+	if _, err := p.VerifyIDToken(ctx, signIDToken(t, jwt.SigningMethodRS256, "rsa-1", oldKey, validClaims(fp.issuer, "n")), "n"); err != nil {
+		t.Fatalf("VerifyIDToken with the first key : %v", err)
+	}
+
+	newKey := newRSAKey(t)
+	fp.publishRSA("rsa-2", &newKey.PublicKey)
+	raw := signIDToken(t, jwt.SigningMethodRS256, "rsa-2", newKey, validClaims(fp.issuer, "n"))
+
+	// The keys were just fetched, an unknown kid doesn't hammer the provider.
+	if _, err := p.VerifyIDToken(ctx, raw, "n"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("VerifyIDToken right after a fetch = %v, want ErrInvalidIDToken", err)
+	}
+
+	p.keys.fetchedAt = time.Now().Add(-keyRefreshInterval)
+
+	if _, err := p.VerifyIDToken(ctx, raw, "n"); err != nil {
+		t.Fatalf("VerifyIDToken with the rotated key : %v", err)
+	}
+}
+
+func TestDiscoverRejectsIssuerMismatch(t *testing.T) {
+	fp := newFakeProvider(t)
+	fp.issuer = "https://evil.example.com"
+
+	p := fp.provider()
+
+	if _, err := p.Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Fatalf("Discover = %v, want an issuer mismatch", err)
+	}
+
+	if _, err := p.AuthCodeURL(context.Background(), "state", "nonce", "verifier"); err == nil {
+		t.Fatal("AuthCodeURL worked with a mismatched issuer")
+	}
+}
+
+func TestDiscoverRequiresEndpoints(t *testing.T) {
+	fp := newFakeProvider(t)
+	fp.noJWKS = true
+
+	if _, err := fp.provider().Discover(context.Background()); err == nil || !strings.Contains(err.Error(), "missing endpoints") {
+		t.Fatalf("Discover = %v, want missing endpoints", err)
+	}
+}
+
+func TestClaimsIsEmailVerified(t *testing.T) {
+	for value, want := range map[interface{}]bool{
+		true:    true,
+		false:   false,
+		"true":  true,
+		"TRUE":  true,
+		"false": false,
+		1.0:     false,
+	} {
+		c := &Claims{EmailVerified: value}
+		if got := c.IsEmailVerified(); got != want {
+			t.Errorf("IsEmailVerified(%#v) = %v, want %v", value, got, want)
+		}
+	}
+
+	if (&Claims{}).IsEmailVerified() {
+		t.Error("IsEmailVerified without the claim = true, want false")
+	}
+}
@@ -0,0 +1,75 @@
+package oidc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]*Provider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: map[string]*Provider{}}
+}
+
+func (r *Registry) Add(p *Provider) {
+	r.providers[p.Name] = p
+}
+
+func (r *Registry) Get(name string) (*Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	p, ok := r.providers[name]
+
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	names := []string{}
+	if r == nil {
+		return names
+	}
+
+	for name := range r.providers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Load registers the configured providers. The redirect URL defaults to the
+// callback route under baseURL.
+func Load(cfg config.OIDC, baseURL string) *Registry {
+	r := NewRegistry()
+
+	for _, p := range cfg.Providers {
+		c := Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}
+
+		if c.RedirectURL == "" {
+			c.RedirectURL = strings.TrimSuffix(baseURL, "/") + "/v1/user/oidc/" + c.Name + "/callback"
+		}
+
+		if len(c.Scopes) == 0 {
+			c.Scopes = []string{"openid", "email", "profile"}
+		}
+
+		r.Add(NewProvider(c))
+	}
+
+	return r
+}
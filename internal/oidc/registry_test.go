@@ -0,0 +1,47 @@
+package oidc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+func TestLoadAppliesDefaults(t *testing.T) {
+	r := Load(config.OIDC{Providers: []config.OIDCProvider{
+		{Name: "google", Issuer: "https://accounts.google.com", ClientID: "google-client"},
+		{Name: "mock", Issuer: "http://127.0.0.1:8080", ClientID: "mock-client", RedirectURL: "http://app.example.com/callback", Scopes: []string{"openid"}},
+	}}, "http://127.0.0.1:5000/")
+
+	if names := r.Names(); !reflect.DeepEqual(names, []string{"google", "mock"}) {
+		t.Fatalf("Names = %v, want [google mock]", names)
+	}
+
+	google, _ := r.Get("google")
+	if google.RedirectURL != "http://127.0.0.1:5000/v1/user/oidc/google/callback" {
+		t.Errorf("default redirect URL = %q", google.RedirectURL)
+	}
+	if !reflect.DeepEqual(google.Scopes, []string{"openid", "email", "profile"}) {
+		t.Errorf("default scopes = %v", google.Scopes)
+	}
+
+	mock, _ := r.Get("mock")
+	if mock.RedirectURL != "http://app.example.com/callback" || !reflect.DeepEqual(mock.Scopes, []string{"openid"}) {
+		t.Errorf("configured provider = %+v, want its redirect URL and scopes kept", mock.Config)
+	}
+
+	if _, ok := r.Get("github"); ok {
+		t.Error("Get found a provider that isn't configured")
+	}
+}
+
+func TestNilRegistry(t *testing.T) {
+	var r *Registry
+
+	if _, ok := r.Get("google"); ok {
+		t.Error("Get on a nil registry found a provider")
+	}
+	if names := r.Names(); len(names) != 0 {
+		t.Errorf("Names on a nil registry = %v, want none", names)
+	}
+}
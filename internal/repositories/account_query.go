@@ -0,0 +1,229 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type AccountQueries struct {
+	*sqlx.DB
+}
+
+func (q *AccountQueries) GetExportCats(userId uuid.UUID) ([]models.ExportCat, error) {
+	query := `SELECT id, name, race, sex, ageinmonth, description, hasmatched, imageurls, sire_id, dam_id, litter_id, created_at, updated_at, deleted_at
+	FROM cats WHERE user_id = $1 ORDER BY created_at`
+
+	rows, err := q.Query(query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	cats := []models.ExportCat{}
+	for rows.Next() {
+		var cat models.ExportCat
+		var imageUrls pgtype.TextArray
+
+		err := rows.Scan(
+			&cat.ID,
+			&cat.Name,
+			&cat.Race,
+			&cat.Sex,
+			&cat.AgeInMonth,
+			&cat.Description,
+			&cat.HasMatched,
+			&imageUrls,
+			&cat.SireID,
+			&cat.DamID,
+			&cat.LitterID,
+			&cat.CreatedAt,
+			&cat.UpdatedAt,
+			&cat.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		cat.ImageUrls = []string{}
+		if err := imageUrls.AssignTo(&cat.ImageUrls); err != nil {
+			return nil, err
+		}
+
+		cats = append(cats, cat)
+	}
+
+	return cats, rows.Err()
+}
+
+func (q *AccountQueries) GetExportMatches(userId uuid.UUID) ([]models.ExportMatch, error) {
+	matches := []models.ExportMatch{}
+
+	query := `SELECT m.id, m.cat_issuer_id, m.cat_match_id, m.message, m.status, m.created_at, m.updated_at, m.deleted_at,
+		CASE WHEN issuer.user_id = $1 THEN 'sent' ELSE 'received' END AS direction
+	FROM cat_matches m
+	JOIN cats issuer ON issuer.id = m.cat_issuer_id
+	JOIN cats matched ON matched.id = m.cat_match_id
+	WHERE issuer.user_id = $1 OR matched.user_id = $1
+	ORDER BY m.created_at`
+
+	if err := q.Select(&matches, query, userId); err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func (q *AccountQueries) GetExportMessages(userId uuid.UUID) ([]models.ExportMessage, error) {
+	messages := []models.ExportMessage{}
+
+	query := `SELECT msg.id, msg.cat_match_id, msg.sender_id, msg.sender_id = $1 AS sent, msg.body, msg.read_at, msg.created_at, msg.deleted_at
+	FROM cat_match_messages msg
+	JOIN cat_matches m ON m.id = msg.cat_match_id
+	JOIN cats issuer ON issuer.id = m.cat_issuer_id
+	JOIN cats matched ON matched.id = m.cat_match_id
+	WHERE (issuer.user_id = $1 OR matched.user_id = $1)
+	AND (msg.sender_id = $1 OR msg.deleted_at IS NULL)
+	ORDER BY msg.cat_match_id, msg.created_at`
+
+	if err := q.Select(&messages, query, userId); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+// AnonymizeUser deletes an account but keeps the row until purgeAfter, so the
+// matches and conversations of the other users stay consistent. The personal
+// fields are overwritten right away and the user is signed out everywhere.
+// It returns the pending matches it withdrew.
+func (q *AccountQueries) AnonymizeUser(userId uuid.UUID, purgeAfter time.Time) ([]models.WithdrawnMatch, error) {
+	tx, err := q.Beginx()
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	// An empty hash never matches a password.
+	query := `UPDATE users SET email = $2, name = 'Deleted user', password = '', bio = '', avatar_url = '',
+		email_verified_at = NULL, tokens_revoked_at = NOW(), updated_at = NOW(), deleted_at = NOW(), purge_after = $3
+	WHERE id = $1 AND deleted_at IS NULL`
+
+	res, err := tx.Exec(query, userId, fmt.Sprintf("deleted-%s@deleted.invalid", userId), purgeAfter)
+	if err != nil {
+		return nil, err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	withdrawn := []models.WithdrawnMatch{}
+
+	query = `UPDATE cat_matches m SET status = 'withdrawn', deleted_at = NOW()
+	FROM cats issuer, cats matched
+	WHERE issuer.id = m.cat_issuer_id AND matched.id = m.cat_match_id
+	AND m.status = 'pending' AND m.deleted_at IS NULL
+	AND (issuer.user_id = $1 OR matched.user_id = $1)
+	RETURNING m.*, CASE WHEN issuer.user_id = $1 THEN matched.user_id ELSE issuer.user_id END AS recipient_id`
+
+	if err := tx.Select(&withdrawn, query, userId); err != nil {
+		return nil, err
+	}
+
+	statements := []string{
+		`UPDATE cats SET deleted_at = NOW() WHERE user_id = $1 AND deleted_at IS NULL`,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`,
+		`DELETE FROM user_identities WHERE user_id = $1`,
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+		`DELETE FROM mfa_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM password_reset_tokens WHERE user_id = $1`,
+		`DELETE FROM notifications WHERE user_id = $1`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, userId); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := revokeUserSessions(tx, userId); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return withdrawn, nil
+}
+
+// PurgeDeletedUsers hard deletes the accounts whose grace period ended. The
+// messages, health records and cats of the user go with them, except the
+// cats other records still point to, e.g. the parent of another user's cat
+// or a side of an approved match. Those cats stay soft deleted and keep the
+// anonymized user row alive, which is then only marked as purged.
+func (q *AccountQueries) PurgeDeletedUsers(now time.Time) (int64, error) {
+	tx, err := q.Beginx()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	purgeable := `SELECT id FROM users WHERE deleted_at IS NOT NULL AND purged_at IS NULL AND purge_after <= $1`
+	userCats := `SELECT id FROM cats WHERE user_id IN (` + purgeable + `)`
+
+	statements := []string{
+		`DELETE FROM cat_match_messages WHERE sender_id IN (` + purgeable + `)`,
+		`DELETE FROM cat_health_shares WHERE shared_by IN (` + purgeable + `)`,
+		`DELETE FROM cat_health_records WHERE cat_id IN (` + userCats + `)`,
+		`DELETE FROM cats c WHERE c.id IN (` + userCats + `)
+		AND NOT EXISTS (SELECT 1 FROM cat_matches m WHERE m.cat_issuer_id = c.id OR m.cat_match_id = c.id)
+		AND NOT EXISTS (SELECT 1 FROM cats child WHERE child.sire_id = c.id OR child.dam_id = c.id)
+		AND NOT EXISTS (SELECT 1 FROM litters l WHERE l.sire_id = c.id OR l.dam_id = c.id)
+		AND NOT EXISTS (SELECT 1 FROM cat_health_shares s WHERE s.cat_id = c.id)`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, now); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := tx.Exec(`DELETE FROM users u WHERE u.id IN (`+purgeable+`)
+		AND NOT EXISTS (SELECT 1 FROM cats c WHERE c.user_id = u.id)
+		AND NOT EXISTS (SELECT 1 FROM litters l WHERE l.registered_by = u.id)`, now)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	res, err = tx.Exec(`UPDATE users SET purged_at = NOW() WHERE id IN (`+purgeable+`)`, now)
+	if err != nil {
+		return 0, err
+	}
+	kept, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return deleted + kept, nil
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type APIKeyQueries struct {
+	*sqlx.DB
+}
+
+func (q *APIKeyQueries) CreateAPIKey(k *models.APIKey) error {
+	query := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	_, err := q.Exec(query, k.ID, k.UserID, k.Name, k.Prefix, k.KeyHash, k.Scopes, k.ExpiresAt, k.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeysByUserId lists the keys of the user, revoked ones included.
+func (q *APIKeyQueries) GetAPIKeysByUserId(userId uuid.UUID) ([]models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys
+	WHERE user_id = $1
+	ORDER BY created_at DESC`
+
+	return q.selectAPIKeys(query, userId)
+}
+
+// GetAPIKeyByHash returns sql.ErrNoRows when no key has this hash.
+func (q *APIKeyQueries) GetAPIKeyByHash(keyHash string) (models.APIKey, error) {
+	query := `SELECT id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at
+	FROM api_keys
+	WHERE key_hash = $1`
+
+	keys, err := q.selectAPIKeys(query, keyHash)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+
+	if len(keys) == 0 {
+		return models.APIKey{}, sql.ErrNoRows
+	}
+
+	return keys[0], nil
+}
+
+func (q *APIKeyQueries) CountActiveAPIKeys(userId uuid.UUID) (int, error) {
+	var count int
+
+	query := `SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`
+
+	if err := q.Get(&count, query, userId); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// TouchAPIKey records when the key was last used, at most once a minute so a
+// busy integration doesn't write on every request.
+func (q *APIKeyQueries) TouchAPIKey(id uuid.UUID) error {
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+
+	_, err := q.Exec(query, id)
+
+	return err
+}
+
+// RevokeAPIKey returns false when the user has no active key with this ID.
+func (q *APIKeyQueries) RevokeAPIKey(id uuid.UUID, userId uuid.UUID) (bool, error) {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := q.Exec(query, id, userId)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (q *APIKeyQueries) selectAPIKeys(query string, args ...interface{}) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var key = models.APIKey{}
+		var scopes pgtype.TextArray
+		var err = rows.Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.KeyHash,
+			&scopes,
+			&key.ExpiresAt,
+			&key.LastUsedAt,
+			&key.RevokedAt,
+			&key.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := scopes.AssignTo(&key.Scopes); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type AuditQueries struct {
+	*sqlx.DB
+}
+
+func (q *AuditQueries) CreateAuditEvent(e *models.AuditEvent) error {
+	query := `INSERT INTO audit_events (id, actor_id, action, target_type, target_id, ip, user_agent, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := q.Exec(query, e.ID, e.ActorID, e.Action, e.TargetType, e.TargetID, e.IP, e.UserAgent, string(e.Metadata), e.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAuditEvents returns the matching events, newest first, and the total
+// number of matches for paging.
+func (q *AuditQueries) GetAuditEvents(f models.AuditEventFilter) ([]models.AuditEvent, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	add := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if f.ActorID != nil {
+		add("actor_id = $%d", *f.ActorID)
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.TargetType != "" {
+		add("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		add("target_id = $%d", f.TargetID)
+	}
+	if f.IP != "" {
+		add("ip = $%d", f.IP)
+	}
+	if f.From != nil {
+		add("created_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("created_at < $%d", *f.To)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := q.Get(&total, "SELECT COUNT(*) FROM audit_events"+where, args...); err != nil {
+		return nil, 0, err
+	}
+
+	query := `SELECT id, actor_id, action, target_type, target_id, ip, user_agent, metadata, created_at
+		FROM audit_events` + where + fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)+1, len(args)+2)
+
+	events := []models.AuditEvent{}
+	if err := q.Select(&events, query, append(args, f.Limit, f.Offset)...); err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
@@ -27,7 +27,7 @@ func (q *CatMatchQueries) CreateCatMatch(cm *models.CatMatch) error {
 func (q *CatMatchQueries) GetCatMatchByCatIds(match_catId uuid.UUID, issuer_catId uuid.UUID) ([]models.CatMatch, error) {
 	cat_matches := []models.CatMatch{}
 
-	query := `SELECT * FROM cat_matches WHERE cat_issuer_id = $1 AND cat_match_id = $2`
+	query := `SELECT * FROM cat_matches WHERE cat_issuer_id = $1 AND cat_match_id = $2 AND deleted_at IS NULL`
 
 	if err := q.Select(&cat_matches, query, match_catId, issuer_catId); err != nil {
 		return nil, err
@@ -68,7 +68,7 @@ func (q *CatMatchQueries) GetCatMatchRequests(cat_id uuid.UUID) ([]models.CatMat
 	LEFT JOIN cats ci ON cat_matches.cat_issuer_id = ci.id
 	LEFT JOIN cats cm ON cat_matches.cat_match_id = cm.id
 	LEFT JOIN users u ON ci.user_id = u.id
-	WHERE cat_issuer_id = $1 OR cat_match_id = $1
+	WHERE (cat_issuer_id = $1 OR cat_match_id = $1) AND cat_matches.deleted_at IS NULL
 	ORDER BY created_at DESC
 	`
 	rows, err := q.Query(query, cat_id)
@@ -135,6 +135,22 @@ func (q *CatMatchQueries) UpdateCatMatch(id uuid.UUID, status string) error {
 func (q *CatMatchQueries) GetCatMatchById(id uuid.UUID) ([]models.CatMatch, error) {
 	catmatch := []models.CatMatch{}
 
+	// Withdrawn matches are soft deleted and can't be acted on anymore.
+	query := `SELECT * FROM cat_matches WHERE id = $1 AND deleted_at IS NULL`
+
+	err := q.Select(&catmatch, query, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return catmatch, nil
+}
+
+// GetCatMatchByIdIncludingWithdrawn also finds withdrawn matches, their
+// conversation stays readable.
+func (q *CatMatchQueries) GetCatMatchByIdIncludingWithdrawn(id uuid.UUID) ([]models.CatMatch, error) {
+	catmatch := []models.CatMatch{}
+
 	query := `SELECT * FROM cat_matches WHERE id = $1`
 
 	err := q.Select(&catmatch, query, id)
@@ -145,8 +161,9 @@ func (q *CatMatchQueries) GetCatMatchById(id uuid.UUID) ([]models.CatMatch, erro
 	return catmatch, nil
 }
 
+// Matches are withdrawn instead of removed so their conversation stays readable.
 func (q *CatMatchQueries) DeleteCatMatchExceptNotPending(id uuid.UUID) error {
-	query := `DELETE FROM cat_matches WHERE id = $1 AND status = 'pending'`
+	query := `UPDATE cat_matches SET status = 'withdrawn', deleted_at = NOW() WHERE id = $1 AND status = 'pending'`
 
 	_, err := q.Exec(query, id)
 
@@ -158,7 +175,7 @@ func (q *CatMatchQueries) DeleteCatMatchExceptNotPending(id uuid.UUID) error {
 }
 
 func (q *CatMatchQueries) DeleteCatMatchById(id uuid.UUID) error {
-	query := `DELETE FROM cat_matches WHERE id = $1`
+	query := `UPDATE cat_matches SET status = 'withdrawn', deleted_at = NOW() WHERE id = $1`
 
 	_, err := q.Exec(query, id)
 
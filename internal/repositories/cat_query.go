@@ -17,7 +17,7 @@ type CatQueries struct {
 func (q *CatQueries) GetCats() ([]models.Cats, error) {
 	cats := []models.Cats{}
 
-	query := `SELECT * FROM cats AND deleted_at IS NULL`
+	query := `SELECT id, user_id, name, race, sex, ageinmonth, description, hasmatched, imageurls, created_at, updated_at, deleted_at, sire_id, dam_id, litter_id FROM cats WHERE deleted_at IS NULL`
 
  	rows, err := q.Query(query)
 
@@ -42,6 +42,9 @@ func (q *CatQueries) GetCats() ([]models.Cats, error) {
 			&cat.CreatedAt,
 			&cat.UpdatedAt,
 			&cat.DeletedAt,
+			&cat.SireID,
+			&cat.DamID,
+			&cat.LitterID,
 		)
 		if err != nil {
 			return nil, err
@@ -54,7 +57,7 @@ func (q *CatQueries) GetCats() ([]models.Cats, error) {
 }
 
 func (q *CatQueries) GetCatsData(appendQuery string) ([]models.CatData, error) {
-	query := "SELECT id,name,race,sex,ageinmonth,imageurls,description,hasmatched,created_at FROM cats WHERE deleted_at IS NULL"
+	query := "SELECT id,name,race,sex,ageinmonth,imageurls,description,hasmatched,sire_id,dam_id,litter_id,created_at FROM cats WHERE deleted_at IS NULL"
 	rows, err := q.Query(query + appendQuery)
 	if err != nil {
 		return nil, err
@@ -73,6 +76,9 @@ func (q *CatQueries) GetCatsData(appendQuery string) ([]models.CatData, error) {
 			&imgUrlStr,
 			&each.Description,
 			&each.HasMatched,
+			&each.SireID,
+			&each.DamID,
+			&each.LitterID,
 			&each.CreatedAt,
 		)
 		if err != nil {
@@ -88,7 +94,7 @@ func (q *CatQueries) GetCatsData(appendQuery string) ([]models.CatData, error) {
 func (q *CatQueries) GetCatById(id uuid.UUID) ([]models.Cats, error) {
 	cats := []models.Cats{}
 
-	query := `SELECT * FROM cats WHERE id = $1 AND deleted_at IS NULL`
+	query := `SELECT id, user_id, name, race, sex, ageinmonth, description, hasmatched, imageurls, created_at, updated_at, deleted_at, sire_id, dam_id, litter_id FROM cats WHERE id = $1 AND deleted_at IS NULL`
 
 	rows, err := q.Query(query, id)
 
@@ -113,6 +119,9 @@ func (q *CatQueries) GetCatById(id uuid.UUID) ([]models.Cats, error) {
 			&cat.CreatedAt,
 			&cat.UpdatedAt,
 			&cat.DeletedAt,
+			&cat.SireID,
+			&cat.DamID,
+			&cat.LitterID,
 		)
 		if err != nil {
 			return nil, err
@@ -127,7 +136,7 @@ func (q *CatQueries) GetCatById(id uuid.UUID) ([]models.Cats, error) {
 func (q *CatQueries) GetCatsByUserId(userId uuid.UUID) ([]models.Cats, error) {
 	cats := []models.Cats{}
 
-	query := `SELECT * FROM cats WHERE user_id = $1 AND deleted_at IS NULL`
+	query := `SELECT id, user_id, name, race, sex, ageinmonth, description, hasmatched, imageurls, created_at, updated_at, deleted_at, sire_id, dam_id, litter_id FROM cats WHERE user_id = $1 AND deleted_at IS NULL`
 
 	rows, err := q.Query(query, userId)
 
@@ -152,6 +161,9 @@ func (q *CatQueries) GetCatsByUserId(userId uuid.UUID) ([]models.Cats, error) {
 			&cat.CreatedAt,
 			&cat.UpdatedAt,
 			&cat.DeletedAt,
+			&cat.SireID,
+			&cat.DamID,
+			&cat.LitterID,
 		)
 		if err != nil {
 			return nil, err
@@ -176,9 +188,12 @@ func (q *CatMatchQueries) UpdateCatHasMatched(id uuid.UUID) error {
 }
 
 func (q *CatQueries) UpdateCat(id uuid.UUID, c *models.CatUpdateRequest) error {
-	query := `UPDATE cats SET name = $2, race = $3, sex = $4, ageinmonth = $5, description = $6, imageurls = $7 WHERE id = $1`
+	query := `UPDATE cats SET name = $2, race = $3, sex = $4, ageinmonth = $5, description = $6, imageurls = $7,
+		sire_id = CASE WHEN $10 THEN NULL ELSE COALESCE($8, sire_id) END,
+		dam_id = CASE WHEN $11 THEN NULL ELSE COALESCE($9, dam_id) END
+		WHERE id = $1`
 
-	_, err := q.Exec(query, id, c.Name, c.Race, c.Sex, c.AgeInMonth, c.Description, c.ImageUrls)
+	_, err := q.Exec(query, id, c.Name, c.Race, c.Sex, c.AgeInMonth, c.Description, c.ImageUrls, c.SireID, c.DamID, c.ClearSire, c.ClearDam)
 	if err != nil {
 		return err
 	}
@@ -187,8 +202,8 @@ func (q *CatQueries) UpdateCat(id uuid.UUID, c *models.CatUpdateRequest) error {
 }
 
 func (q *CatQueries) CreateCat(c *models.Cat) error {
-	query := `INSERT INTO cats (id, user_id, name, race, sex, ageinmonth, description, imageurls, hasmatched, created_at)
-           VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`
+	query := `INSERT INTO cats (id, user_id, name, race, sex, ageinmonth, description, imageurls, hasmatched, created_at, sire_id, dam_id)
+           VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
 	_, err := q.Exec(
 		query,
@@ -202,6 +217,8 @@ func (q *CatQueries) CreateCat(c *models.Cat) error {
 		c.ImageUrls,
 		c.HasMatched,
 		c.CreatedAt,
+		c.SireID,
+		c.DamID,
 	)
 	if err != nil {
 		return err
@@ -226,4 +243,87 @@ func (q *CatQueries) DeleteCat(catId string, userID string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+func (q *CatQueries) GetCatAncestors(id uuid.UUID, generations int) ([]models.CatAncestor, error) {
+	ancestors := []models.CatAncestor{}
+
+	query := `WITH RECURSIVE pedigree AS (
+		SELECT id, name, race, sex, sire_id, dam_id, 0 AS generation
+		FROM cats WHERE id = $1
+		UNION
+		SELECT c.id, c.name, c.race, c.sex, c.sire_id, c.dam_id, p.generation + 1
+		FROM cats c
+		JOIN pedigree p ON c.id = p.sire_id OR c.id = p.dam_id
+		WHERE p.generation < $2
+	)
+	SELECT DISTINCT ON (id) id, name, race, sex, sire_id, dam_id, generation
+	FROM pedigree
+	ORDER BY id, generation`
+
+	if err := q.Select(&ancestors, query, id, generations); err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+// GetCatsAncestors walks the pedigrees of several cats in one query, the
+// ancestors are grouped by the cat they were found from.
+func (q *CatQueries) GetCatsAncestors(ids []uuid.UUID, generations int) (map[uuid.UUID][]models.CatAncestor, error) {
+	ancestors := map[uuid.UUID][]models.CatAncestor{}
+	if len(ids) == 0 {
+		return ancestors, nil
+	}
+
+	rootIds := make([]string, 0, len(ids))
+	for _, id := range ids {
+		rootIds = append(rootIds, id.String())
+	}
+
+	rows := []struct {
+		RootID uuid.UUID `db:"root_id"`
+		models.CatAncestor
+	}{}
+
+	query := `WITH RECURSIVE pedigree AS (
+		SELECT id AS root_id, id, name, race, sex, sire_id, dam_id, 0 AS generation
+		FROM cats WHERE id = ANY($1::uuid[])
+		UNION
+		SELECT p.root_id, c.id, c.name, c.race, c.sex, c.sire_id, c.dam_id, p.generation + 1
+		FROM cats c
+		JOIN pedigree p ON c.id = p.sire_id OR c.id = p.dam_id
+		WHERE p.generation < $2
+	)
+	SELECT DISTINCT ON (root_id, id) root_id, id, name, race, sex, sire_id, dam_id, generation
+	FROM pedigree
+	ORDER BY root_id, id, generation`
+
+	if err := q.Select(&rows, query, rootIds, generations); err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		ancestors[row.RootID] = append(ancestors[row.RootID], row.CatAncestor)
+	}
+
+	return ancestors, nil
+}
+
+func (q *CatQueries) IsCatAncestor(ancestorId uuid.UUID, catId uuid.UUID) (bool, error) {
+	var isAncestor bool
+
+	query := `WITH RECURSIVE lineage AS (
+		SELECT id, sire_id, dam_id FROM cats WHERE id = $1
+		UNION
+		SELECT c.id, c.sire_id, c.dam_id
+		FROM cats c
+		JOIN lineage l ON c.id = l.sire_id OR c.id = l.dam_id
+	)
+	SELECT EXISTS (SELECT 1 FROM lineage WHERE id = $2)`
+
+	if err := q.Get(&isAncestor, query, catId, ancestorId); err != nil {
+		return false, err
+	}
+
+	return isAncestor, nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// ErrChatCursorNotFound is returned when the cursor isn't a message of the
+// thread.
+var ErrChatCursorNotFound = errors.New("cursor is not a message of this conversation")
+
+type ChatQueries struct {
+	*sqlx.DB
+}
+
+func (q *ChatQueries) CreateChatMessage(m *models.ChatMessage) error {
+	query := `INSERT INTO cat_match_messages (id, cat_match_id, sender_id, body, created_at) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := q.Exec(query, m.ID, m.CatMatchID, m.SenderID, m.Body, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetChatMessages returns the newest messages of a thread, older than the
+// message given as cursor when it is not nil. A deleted message is still a
+// valid cursor.
+func (q *ChatQueries) GetChatMessages(catMatchId uuid.UUID, before *uuid.UUID, limit int) ([]models.ChatMessage, error) {
+	messages := []models.ChatMessage{}
+
+	if before != nil {
+		var found bool
+		if err := q.Get(&found, `SELECT EXISTS (SELECT 1 FROM cat_match_messages WHERE id = $1 AND cat_match_id = $2)`, *before, catMatchId); err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, ErrChatCursorNotFound
+		}
+	}
+
+	query := `SELECT id, cat_match_id, sender_id, body, read_at, created_at
+	FROM cat_match_messages
+	WHERE cat_match_id = $1 AND deleted_at IS NULL
+	AND ($2::uuid IS NULL OR created_at < (SELECT created_at FROM cat_match_messages WHERE id = $2 AND cat_match_id = $1))
+	ORDER BY created_at DESC
+	LIMIT $3`
+
+	if err := q.Select(&messages, query, catMatchId, before, limit); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (q *ChatQueries) DeleteChatMessage(id uuid.UUID, catMatchId uuid.UUID, senderId uuid.UUID) error {
+	query := `UPDATE cat_match_messages SET deleted_at = NOW()
+	WHERE id = $1 AND cat_match_id = $2 AND sender_id = $3 AND deleted_at IS NULL`
+
+	res, err := q.Exec(query, id, catMatchId, senderId)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no id found")
+	}
+
+	return nil
+}
+
+// MarkChatMessagesRead sets the read receipt of every message the reader
+// received in the thread and returns how many were marked.
+func (q *ChatQueries) MarkChatMessagesRead(catMatchId uuid.UUID, readerId uuid.UUID) (int64, error) {
+	query := `UPDATE cat_match_messages SET read_at = NOW()
+	WHERE cat_match_id = $1 AND sender_id <> $2 AND read_at IS NULL AND deleted_at IS NULL`
+
+	res, err := q.Exec(query, catMatchId, readerId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
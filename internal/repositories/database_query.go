@@ -0,0 +1,32 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type DatabaseQueries struct {
+	*sqlx.DB
+}
+
+func (q *DatabaseQueries) PingDatabase(ctx context.Context) error {
+	return q.PingContext(ctx)
+}
+
+func (q *DatabaseQueries) GetDatabaseStats() models.DatabaseStats {
+	stats := q.Stats()
+
+	return models.DatabaseStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+}
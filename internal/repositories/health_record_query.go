@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type HealthRecordQueries struct {
+	*sqlx.DB
+}
+
+func (q *HealthRecordQueries) GetHealthRecordsByCatId(catId uuid.UUID) ([]models.HealthRecord, error) {
+	query := `SELECT id, cat_id, record_type, title, notes, performed_at, expires_at, document_urls, created_at, updated_at, deleted_at
+	FROM cat_health_records
+	WHERE cat_id = $1 AND deleted_at IS NULL
+	ORDER BY performed_at DESC`
+
+	return q.selectHealthRecords(query, catId)
+}
+
+func (q *HealthRecordQueries) GetHealthRecordById(id uuid.UUID, catId uuid.UUID) ([]models.HealthRecord, error) {
+	query := `SELECT id, cat_id, record_type, title, notes, performed_at, expires_at, document_urls, created_at, updated_at, deleted_at
+	FROM cat_health_records
+	WHERE id = $1 AND cat_id = $2 AND deleted_at IS NULL`
+
+	return q.selectHealthRecords(query, id, catId)
+}
+
+func (q *HealthRecordQueries) selectHealthRecords(query string, args ...interface{}) ([]models.HealthRecord, error) {
+	records := []models.HealthRecord{}
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var record = models.HealthRecord{}
+		var documentUrls pgtype.TextArray
+		var err = rows.Scan(
+			&record.ID,
+			&record.CatID,
+			&record.RecordType,
+			&record.Title,
+			&record.Notes,
+			&record.PerformedAt,
+			&record.ExpiresAt,
+			&documentUrls,
+			&record.CreatedAt,
+			&record.UpdatedAt,
+			&record.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := documentUrls.AssignTo(&record.DocumentUrls); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+func (q *HealthRecordQueries) CreateHealthRecord(r *models.HealthRecord) error {
+	query := `INSERT INTO cat_health_records (id, cat_id, record_type, title, notes, performed_at, expires_at, document_urls, created_at)
+           VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := q.Exec(
+		query,
+		r.ID,
+		r.CatID,
+		r.RecordType,
+		r.Title,
+		r.Notes,
+		r.PerformedAt,
+		r.ExpiresAt,
+		r.DocumentUrls,
+		r.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *HealthRecordQueries) UpdateHealthRecord(r *models.HealthRecord) error {
+	query := `UPDATE cat_health_records
+	SET record_type = $3, title = $4, notes = $5, performed_at = $6, expires_at = $7, document_urls = $8, updated_at = $9
+	WHERE id = $1 AND cat_id = $2 AND deleted_at IS NULL`
+
+	_, err := q.Exec(
+		query,
+		r.ID,
+		r.CatID,
+		r.RecordType,
+		r.Title,
+		r.Notes,
+		r.PerformedAt,
+		r.ExpiresAt,
+		r.DocumentUrls,
+		r.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *HealthRecordQueries) DeleteHealthRecord(id uuid.UUID, catId uuid.UUID) error {
+	query := `UPDATE cat_health_records SET deleted_at = $1 WHERE id = $2 AND cat_id = $3 AND deleted_at IS NULL`
+
+	res, err := q.Exec(query, time.Now(), id, catId)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no id found")
+	}
+
+	return nil
+}
+
+// HasUpToDateVaccinations reports whether the cat has at least one vaccination
+// and the latest record of every vaccination is not expired yet. A record
+// without an expiry date can't prove anything and counts as expired.
+func (q *HealthRecordQueries) HasUpToDateVaccinations(catId uuid.UUID) (bool, error) {
+	var upToDate bool
+
+	query := `WITH latest AS (
+		SELECT DISTINCT ON (LOWER(title)) expires_at
+		FROM cat_health_records
+		WHERE cat_id = $1 AND record_type = 'vaccination' AND deleted_at IS NULL
+		ORDER BY LOWER(title), performed_at DESC
+	)
+	SELECT COUNT(*) > 0 AND COUNT(*) FILTER (WHERE expires_at IS NULL OR expires_at < CURRENT_DATE) = 0 FROM latest`
+
+	if err := q.Get(&upToDate, query, catId); err != nil {
+		return false, err
+	}
+
+	return upToDate, nil
+}
+
+func (q *HealthRecordQueries) ShareHealthRecords(catMatchId uuid.UUID, catId uuid.UUID, userId uuid.UUID) error {
+	query := `INSERT INTO cat_health_shares (cat_match_id, cat_id, shared_by) VALUES ($1, $2, $3)
+	ON CONFLICT (cat_match_id, cat_id) DO NOTHING`
+
+	_, err := q.Exec(query, catMatchId, catId, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *HealthRecordQueries) UnshareHealthRecords(catMatchId uuid.UUID, catId uuid.UUID) error {
+	query := `DELETE FROM cat_health_shares WHERE cat_match_id = $1 AND cat_id = $2`
+
+	_, err := q.Exec(query, catMatchId, catId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *HealthRecordQueries) IsHealthRecordsShared(catMatchId uuid.UUID, catId uuid.UUID) (bool, error) {
+	var shared bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM cat_health_shares WHERE cat_match_id = $1 AND cat_id = $2)`
+
+	if err := q.Get(&shared, query, catMatchId, catId); err != nil {
+		return false, err
+	}
+
+	return shared, nil
+}
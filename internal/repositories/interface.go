@@ -8,12 +8,38 @@ type DatabaseRepositories struct {
 	*UserQueries
 	*CatQueries
 	*CatMatchQueries
+	*LitterQueries
+	*HealthRecordQueries
+	*ChatQueries
+	*NotificationQueries
+	*PasswordResetQueries
+	*LoginAttemptQueries
+	*MFAQueries
+	*APIKeyQueries
+	*OIDCQueries
+	*SessionQueries
+	*AuditQueries
+	*AccountQueries
+	*DatabaseQueries
 }
 
 func New(db *sqlx.DB) *DatabaseRepositories {
 	return &DatabaseRepositories{
-		UserQueries:     &UserQueries{DB: db},
-		CatQueries:      &CatQueries{DB: db},
-		CatMatchQueries: &CatMatchQueries{DB: db},
+		UserQueries:          &UserQueries{DB: db},
+		CatQueries:           &CatQueries{DB: db},
+		CatMatchQueries:      &CatMatchQueries{DB: db},
+		LitterQueries:        &LitterQueries{DB: db},
+		HealthRecordQueries:  &HealthRecordQueries{DB: db},
+		ChatQueries:          &ChatQueries{DB: db},
+		NotificationQueries:  &NotificationQueries{DB: db},
+		PasswordResetQueries: &PasswordResetQueries{DB: db},
+		LoginAttemptQueries:  &LoginAttemptQueries{DB: db},
+		MFAQueries:           &MFAQueries{DB: db},
+		APIKeyQueries:        &APIKeyQueries{DB: db},
+		OIDCQueries:          &OIDCQueries{DB: db},
+		SessionQueries:       &SessionQueries{DB: db},
+		AuditQueries:         &AuditQueries{DB: db},
+		AccountQueries:       &AccountQueries{DB: db},
+		DatabaseQueries:      &DatabaseQueries{DB: db},
 	}
 }
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type LitterQueries struct {
+	*sqlx.DB
+}
+
+func (q *LitterQueries) CreateLitter(l *models.Litter, kittens []models.Cat) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `INSERT INTO litters (id, cat_match_id, sire_id, dam_id, registered_by, born_at, created_at)
+           VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err = tx.Exec(query, l.ID, l.CatMatchID, l.SireID, l.DamID, l.RegisteredBy, l.BornAt, l.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	kittenQuery := `INSERT INTO cats (id, user_id, name, race, sex, ageinmonth, description, imageurls, hasmatched, created_at, sire_id, dam_id, litter_id)
+           VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+
+	for _, c := range kittens {
+		_, err := tx.Exec(
+			kittenQuery,
+			c.ID,
+			c.UserID,
+			c.Name,
+			c.Race,
+			c.Sex,
+			c.AgeInMonth,
+			c.Description,
+			c.ImageUrls,
+			c.HasMatched,
+			c.CreatedAt,
+			c.SireID,
+			c.DamID,
+			l.ID,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (q *LitterQueries) GetLittersByParentId(catId uuid.UUID) ([]models.Litter, error) {
+	litters := []models.Litter{}
+
+	query := `SELECT id, cat_match_id, sire_id, dam_id, registered_by, born_at, created_at
+	FROM litters
+	WHERE sire_id = $1 OR dam_id = $1
+	ORDER BY born_at DESC`
+
+	if err := q.Select(&litters, query, catId); err != nil {
+		return nil, err
+	}
+
+	kittens := []models.Kitten{}
+
+	kittenQuery := `SELECT id, user_id, litter_id, name, sex
+	FROM cats
+	WHERE deleted_at IS NULL AND litter_id IN (SELECT id FROM litters WHERE sire_id = $1 OR dam_id = $1)
+	ORDER BY created_at`
+
+	if err := q.Select(&kittens, kittenQuery, catId); err != nil {
+		return nil, err
+	}
+
+	for idx := range litters {
+		litters[idx].Kittens = []models.Kitten{}
+		for _, kitten := range kittens {
+			if kitten.LitterID == litters[idx].ID {
+				litters[idx].Kittens = append(litters[idx].Kittens, kitten)
+			}
+		}
+	}
+
+	return litters, nil
+}
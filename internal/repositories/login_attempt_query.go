@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// LoginAttemptQueries is the Postgres store of the login guard, the counters
+// are shared by every instance of the API.
+type LoginAttemptQueries struct {
+	*sqlx.DB
+}
+
+func (q *LoginAttemptQueries) GetLoginAttempt(key string) (models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{}
+
+	query := `SELECT key, failures, last_failure_at, locked_until FROM login_attempts WHERE key = $1`
+
+	err := q.Get(&attempt, query, key)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempt{}, nil
+		}
+		return attempt, err
+	}
+
+	return attempt, nil
+}
+
+// IncrementLoginFailures counts the failure in a single statement, so
+// concurrent failures on several instances are never lost. The counter starts
+// over after a served lockout or when the last failure is older than
+// resetAfter, like loginguard.CounterExpired.
+func (q *LoginAttemptQueries) IncrementLoginFailures(key string, now time.Time, resetAfter time.Duration) (models.LoginAttempt, error) {
+	attempt := models.LoginAttempt{}
+
+	query := `INSERT INTO login_attempts (key, failures, last_failure_at, locked_until)
+		VALUES ($1, 1, $2, NULL)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN ` + loginAttemptExpired + ` THEN 1 ELSE login_attempts.failures + 1 END,
+			locked_until = CASE WHEN ` + loginAttemptExpired + ` THEN NULL ELSE login_attempts.locked_until END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until`
+
+	if err := q.Get(&attempt, query, key, now, now.Add(-resetAfter)); err != nil {
+		return attempt, err
+	}
+
+	return attempt, nil
+}
+
+// loginAttemptExpired reads the row before the update, $2 is now and $3 the
+// oldest failure that still counts.
+const loginAttemptExpired = `(login_attempts.failures = 0
+	OR (login_attempts.locked_until IS NOT NULL AND login_attempts.locked_until <= $2)
+	OR (login_attempts.locked_until IS NULL AND login_attempts.last_failure_at < $3))`
+
+func (q *LoginAttemptQueries) LockLoginAttempt(key string, until time.Time) error {
+	query := `UPDATE login_attempts SET locked_until = GREATEST(COALESCE(locked_until, $2), $2) WHERE key = $1`
+
+	_, err := q.Exec(query, key, until)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *LoginAttemptQueries) DeleteLoginAttempt(key string) error {
+	query := `DELETE FROM login_attempts WHERE key = $1`
+
+	_, err := q.Exec(query, key)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *LoginAttemptQueries) CreateFailedLogin(f *models.FailedLogin) error {
+	query := `INSERT INTO failed_logins (id, email, user_id, ip, user_agent, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.Exec(query, f.ID, f.Email, f.UserID, f.IP, f.UserAgent, f.Reason, f.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
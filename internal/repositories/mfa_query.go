@@ -0,0 +1,184 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type MFAQueries struct {
+	*sqlx.DB
+}
+
+func (q *MFAQueries) GetUserMFA(userId uuid.UUID) (models.UserMFA, error) {
+	mfa := models.UserMFA{}
+
+	query := `SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_mfa WHERE user_id = $1`
+
+	err := q.Get(&mfa, query, userId)
+	if err != nil {
+		return mfa, err
+	}
+
+	return mfa, nil
+}
+
+// CreatePendingUserMFA starts over a pending enrollment, an enabled one is
+// left untouched.
+func (q *MFAQueries) CreatePendingUserMFA(m *models.UserMFA) error {
+	query := `INSERT INTO user_mfa (user_id, secret, enabled_at, last_used_step, created_at)
+		VALUES ($1, $2, NULL, 0, $3)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = 0,
+			created_at = EXCLUDED.created_at
+		WHERE user_mfa.enabled_at IS NULL`
+
+	res, err := q.Exec(query, m.UserID, m.Secret, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("two-factor authentication is already enabled")
+	}
+
+	return nil
+}
+
+// EnableUserMFA activates the enrollment with the verified step and stores a
+// fresh set of recovery codes.
+func (q *MFAQueries) EnableUserMFA(userId uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2 WHERE user_id = $1 AND enabled_at IS NULL`
+
+	res, err := tx.Exec(query, userId, step)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no pending two-factor enrollment found")
+	}
+
+	if err := replaceRecoveryCodes(tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (q *MFAQueries) ReplaceRecoveryCodes(userId uuid.UUID, codeHashes []string) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userId, codeHashes); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sqlx.Tx, userId uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`
+
+		if _, err := tx.Exec(query, userId, codeHash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the step of a verified code. It fails when the step is
+// not newer than the last one, which stops a code from being replayed.
+func (q *MFAQueries) UseTOTPStep(userId uuid.UUID, step int64) error {
+	query := `UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+
+	res, err := q.Exec(query, userId, step)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("code already used")
+	}
+
+	return nil
+}
+
+func (q *MFAQueries) UseRecoveryCode(userId uuid.UUID, codeHash string) error {
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+
+	res, err := q.Exec(query, userId, codeHash)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("invalid recovery code")
+	}
+
+	return nil
+}
+
+func (q *MFAQueries) CountRemainingRecoveryCodes(userId uuid.UUID) (int, error) {
+	var count int
+
+	query := `SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+
+	err := q.Get(&count, query, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (q *MFAQueries) DeleteUserMFA(userId uuid.UUID) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id = $1`, userId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type NotificationQueries struct {
+	*sqlx.DB
+}
+
+func (q *NotificationQueries) CreateNotification(n *models.Notification) error {
+	query := `INSERT INTO notifications (id, user_id, type, actor_id, payload, created_at) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := q.Exec(query, n.ID, n.UserID, n.Type, n.ActorID, string(n.Payload), n.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *NotificationQueries) GetNotifications(userId uuid.UUID, unreadOnly bool, limit int, offset int) ([]models.Notification, error) {
+	notifications := []models.Notification{}
+
+	query := `SELECT id, user_id, type, actor_id, payload, read_at, created_at
+	FROM notifications
+	WHERE user_id = $1 AND (NOT $2 OR read_at IS NULL)
+	ORDER BY created_at DESC
+	LIMIT $3 OFFSET $4`
+
+	if err := q.Select(&notifications, query, userId, unreadOnly, limit, offset); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (q *NotificationQueries) CountUnreadNotifications(userId uuid.UUID) (int, error) {
+	var count int
+
+	query := `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`
+
+	if err := q.Get(&count, query, userId); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (q *NotificationQueries) MarkNotificationRead(id uuid.UUID, userId uuid.UUID) error {
+	query := `UPDATE notifications SET read_at = COALESCE(read_at, NOW()) WHERE id = $1 AND user_id = $2`
+
+	res, err := q.Exec(query, id, userId)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no id found")
+	}
+
+	return nil
+}
+
+func (q *NotificationQueries) MarkAllNotificationsRead(userId uuid.UUID) (int64, error) {
+	query := `UPDATE notifications SET read_at = NOW() WHERE user_id = $1 AND read_at IS NULL`
+
+	res, err := q.Exec(query, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (q *NotificationQueries) DeleteNotificationsBefore(before time.Time) (int64, error) {
+	query := `DELETE FROM notifications WHERE created_at < $1`
+
+	res, err := q.Exec(query, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// GetNotificationPreferences only returns the types the user changed, every
+// other type is enabled.
+func (q *NotificationQueries) GetNotificationPreferences(userId uuid.UUID) ([]models.NotificationPreference, error) {
+	preferences := []models.NotificationPreference{}
+
+	query := `SELECT type, enabled FROM notification_preferences WHERE user_id = $1`
+
+	if err := q.Select(&preferences, query, userId); err != nil {
+		return nil, err
+	}
+
+	return preferences, nil
+}
+
+func (q *NotificationQueries) IsNotificationEnabled(userId uuid.UUID, notificationType string) (bool, error) {
+	var enabled bool
+
+	query := `SELECT COALESCE((SELECT enabled FROM notification_preferences WHERE user_id = $1 AND type = $2), true)`
+
+	if err := q.Get(&enabled, query, userId, notificationType); err != nil {
+		return false, err
+	}
+
+	return enabled, nil
+}
+
+func (q *NotificationQueries) UpsertNotificationPreference(userId uuid.UUID, p models.NotificationPreference) error {
+	query := `INSERT INTO notification_preferences (user_id, type, enabled, updated_at) VALUES ($1, $2, $3, NOW())
+	ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at`
+
+	_, err := q.Exec(query, userId, p.Type, p.Enabled)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type OIDCQueries struct {
+	*sqlx.DB
+}
+
+// CreateOIDCLoginState also drops the expired states, abandoned sign ins
+// don't pile up.
+func (q *OIDCQueries) CreateOIDCLoginState(s *models.OIDCLoginState) error {
+	if _, err := q.Exec(`DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, user_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.Exec(query, s.StateHash, s.Provider, s.CodeVerifier, s.Nonce, s.UserID, s.ExpiresAt, s.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ConsumeOIDCLoginState deletes the state so a callback can't be replayed.
+// Returns sql.ErrNoRows when the state is unknown, used or expired.
+func (q *OIDCQueries) ConsumeOIDCLoginState(stateHash string, provider string) (models.OIDCLoginState, error) {
+	state := models.OIDCLoginState{}
+
+	query := `DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND provider = $2 AND expires_at > NOW()
+		RETURNING state_hash, provider, code_verifier, nonce, user_id, expires_at, created_at`
+
+	err := q.Get(&state, query, stateHash, provider)
+	if err != nil {
+		return state, err
+	}
+
+	return state, nil
+}
+
+func (q *OIDCQueries) GetUserIdentity(provider string, subject string) (models.UserIdentity, error) {
+	identity := models.UserIdentity{}
+
+	query := `SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities WHERE provider = $1 AND subject = $2`
+
+	err := q.Get(&identity, query, provider, subject)
+	if err != nil {
+		return identity, err
+	}
+
+	return identity, nil
+}
+
+func (q *OIDCQueries) GetUserIdentitiesByUserId(userId uuid.UUID) ([]models.UserIdentity, error) {
+	identities := []models.UserIdentity{}
+
+	query := `SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		FROM user_identities WHERE user_id = $1 ORDER BY created_at`
+
+	err := q.Select(&identities, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (q *OIDCQueries) CreateUserIdentity(i *models.UserIdentity) error {
+	query := `INSERT INTO user_identities (id, user_id, provider, subject, email, last_login_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := q.Exec(query, i.ID, i.UserID, i.Provider, i.Subject, i.Email, i.LastLoginAt, i.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateUserWithIdentity signs up a user coming from a provider, the account
+// and its identity are created together.
+func (q *OIDCQueries) CreateUserWithIdentity(u *models.User, i *models.UserIdentity) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (id, email, name, password, user_status, user_role, created_at, updated_at, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	if _, err := tx.Exec(query, u.ID, u.Email, u.Name, u.Password, u.UserStatus, u.UserRole, u.CreatedAt, u.UpdatedAt, u.EmailVerifiedAt); err != nil {
+		return err
+	}
+
+	query = `INSERT INTO user_identities (id, user_id, provider, subject, email, last_login_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	if _, err := tx.Exec(query, i.ID, i.UserID, i.Provider, i.Subject, i.Email, i.LastLoginAt, i.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (q *OIDCQueries) TouchUserIdentity(id uuid.UUID, email string) error {
+	query := `UPDATE user_identities SET last_login_at = NOW(), email = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, email)
+
+	return err
+}
+
+// DeleteUserIdentity returns false when the user has no identity with this ID.
+func (q *OIDCQueries) DeleteUserIdentity(id uuid.UUID, userId uuid.UUID) (bool, error) {
+	res, err := q.Exec(`DELETE FROM user_identities WHERE id = $1 AND user_id = $2`, id, userId)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
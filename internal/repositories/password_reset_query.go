@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type PasswordResetQueries struct {
+	*sqlx.DB
+}
+
+// CreatePasswordResetToken replaces the tokens the user didn't use yet, only
+// the link of the latest email works.
+func (q *PasswordResetQueries) CreatePasswordResetToken(t *models.PasswordResetToken) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, t.UserID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`
+
+	if _, err := tx.Exec(query, t.ID, t.UserID, t.TokenHash, t.ExpiresAt, t.CreatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ResetUserPassword consumes the token and sets the new password hash in one
+// transaction. Tokens issued to the user before now are revoked, so every
+// device has to sign in again. Returns sql.ErrNoRows when the token is
+// unknown, used or expired.
+func (q *PasswordResetQueries) ResetUserPassword(tokenHash string, passwordHash string) (uuid.UUID, error) {
+	var userId uuid.UUID
+
+	tx, err := q.Beginx()
+	if err != nil {
+		return userId, err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+
+	if err := tx.Get(&userId, query, tokenHash); err != nil {
+		return userId, err
+	}
+
+	query = `UPDATE users SET password = $2, updated_at = NOW(), tokens_revoked_at = NOW() WHERE id = $1`
+
+	if _, err := tx.Exec(query, userId, passwordHash); err != nil {
+		return userId, err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL`, userId); err != nil {
+		return userId, err
+	}
+
+	if err := revokeUserSessions(tx, userId); err != nil {
+		return userId, err
+	}
+
+	return userId, tx.Commit()
+}
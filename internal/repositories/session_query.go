@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+type SessionQueries struct {
+	*sqlx.DB
+}
+
+func (q *SessionQueries) CreateSession(s *models.Session) error {
+	query := `INSERT INTO user_sessions (id, user_id, refresh_token_hash, device_name, user_agent, ip, created_at, last_seen_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+	_, err := q.Exec(query, s.ID, s.UserID, s.RefreshTokenHash, s.DeviceName, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetSessionByRefreshTokenHash returns sql.ErrNoRows when no session has this
+// refresh token, e.g. when it was already rotated.
+func (q *SessionQueries) GetSessionByRefreshTokenHash(refreshTokenHash string) (models.Session, error) {
+	session := models.Session{}
+
+	query := `SELECT id, user_id, refresh_token_hash, device_name, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM user_sessions WHERE refresh_token_hash = $1`
+
+	err := q.Get(&session, query, refreshTokenHash)
+	if err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// GetActiveSessionsByUserId lists the devices the user is signed in on.
+func (q *SessionQueries) GetActiveSessionsByUserId(userId uuid.UUID) ([]models.Session, error) {
+	sessions := []models.Session{}
+
+	query := `SELECT id, user_id, refresh_token_hash, device_name, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+
+	err := q.Select(&sessions, query, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RotateSession replaces the refresh token of the session. It only succeeds
+// with the current token, so two renewals racing with the same token can't
+// both win. Returns false when the session was rotated or revoked meanwhile.
+func (q *SessionQueries) RotateSession(s *models.Session, previousHash string) (bool, error) {
+	query := `UPDATE user_sessions
+		SET refresh_token_hash = $3, user_agent = $4, ip = $5, last_seen_at = $6, expires_at = $7
+		WHERE id = $1 AND refresh_token_hash = $2 AND revoked_at IS NULL`
+
+	res, err := q.Exec(query, s.ID, previousHash, s.RefreshTokenHash, s.UserAgent, s.IP, s.LastSeenAt, s.ExpiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// RevokeSession returns false when the user has no active session with this ID.
+func (q *SessionQueries) RevokeSession(id uuid.UUID, userId uuid.UUID) (bool, error) {
+	query := `UPDATE user_sessions SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	res, err := q.Exec(query, id, userId, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// GetTokenStatus returns sql.ErrNoRows when the user doesn't exist anymore. The
+// session is only looked up when sessionId isn't nil.
+func (q *SessionQueries) GetTokenStatus(userId uuid.UUID, sessionId *uuid.UUID) (models.TokenStatus, error) {
+	status := models.TokenStatus{}
+
+	query := `SELECT u.deleted_at AS user_deleted_at, u.tokens_revoked_at,
+		s.id IS NOT NULL AS session_found, s.revoked_at AS session_revoked_at
+		FROM users u
+		LEFT JOIN user_sessions s ON s.id = $2 AND s.user_id = u.id
+		WHERE u.id = $1`
+
+	err := q.Get(&status, query, userId, sessionId)
+	if err != nil {
+		return status, err
+	}
+
+	return status, nil
+}
+
+// revokeUserSessions signs the user out of every device, it runs with the
+// password changes that revoke the tokens of the user.
+func revokeUserSessions(tx sqlx.Execer, userId uuid.UUID) error {
+	_, err := tx.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userId)
+
+	return err
+}
@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"errors"
+
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/ravenocx/cat-socialx/internal/models"
@@ -37,15 +39,103 @@ func (q *UserQueries) GetUserByEmail(email string) (models.User, error) {
 }
 
 func (q *UserQueries) CreateUser(u *models.User) error {
-	query := `INSERT INTO users VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	query := `INSERT INTO users (id, email, name, password, user_status, user_role, created_at, updated_at, email_verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
 
 	_, err := q.Exec(
 		query,
-		u.ID, u.Email, u.Name, u.Password, u.UserStatus, u.UserRole, u.CreatedAt, u.UpdatedAt, 
+		u.ID, u.Email, u.Name, u.Password, u.UserStatus, u.UserRole, u.CreatedAt, u.UpdatedAt, u.EmailVerifiedAt,
 	)
 	if err != nil {
 		return err
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// MarkUserEmailVerified only verifies the address the token was issued for,
+// so a link sent before an email change can't verify the new address.
+func (q *UserQueries) MarkUserEmailVerified(id uuid.UUID, email string) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1 AND email = $2`
+
+	res, err := q.Exec(query, id, email)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return errors.New("no user found for this email")
+	}
+
+	return nil
+}
+
+func (q *UserQueries) UpdateUserProfile(u *models.User) error {
+	query := `UPDATE users SET name = $2, bio = $3, avatar_url = $4, updated_at = NOW() WHERE id = $1
+		RETURNING updated_at`
+
+	return q.Get(&u.UpdatedAt, query, u.ID, u.Name, u.Bio, u.AvatarURL)
+}
+
+// UpdateUserPassword also revokes the tokens issued before the change and
+// signs the user out of every session.
+func (q *UserQueries) UpdateUserPassword(id uuid.UUID, passwordHash string) error {
+	tx, err := q.Beginx()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	query := `UPDATE users SET password = $2, updated_at = NOW(), tokens_revoked_at = NOW() WHERE id = $1`
+
+	if _, err := tx.Exec(query, id, passwordHash); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessions(tx, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateUserEmail resets the verification, the new address has to be
+// verified again.
+func (q *UserQueries) UpdateUserEmail(id uuid.UUID, email string) error {
+	query := `UPDATE users SET email = $2, email_verified_at = NULL, updated_at = NOW() WHERE id = $1`
+
+	_, err := q.Exec(query, id, email)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q *UserQueries) UpdateUserRole(id uuid.UUID, role string) error {
+	query := `UPDATE users SET user_role = $2, updated_at = NOW() WHERE id = $1`
+
+	_, err := q.Exec(query, id, role)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUserPasswordHash swaps the hash of the same password, the tokens of
+// the user stay valid.
+func (q *UserQueries) UpdateUserPasswordHash(id uuid.UUID, passwordHash string) error {
+	query := `UPDATE users SET password = $2 WHERE id = $1`
+
+	_, err := q.Exec(query, id, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/ravenocx/cat-socialx/internal/middleware"
+)
+
+func (i *V1Routes) AdminRoutes() {
+	route := i.Fiber.Group("/v1/admin")
+
+	adminController := i.Controller
+
+	route.Get("/audit-events", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), adminController.GetAuditEvents)
+	route.Get("/database/stats", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), adminController.GetDatabaseStats)
+}
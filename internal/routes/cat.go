@@ -1,19 +1,22 @@
 package routes
 
 import (
-	"github.com/ravenocx/cat-socialx/internal/controllers"
 	"github.com/ravenocx/cat-socialx/internal/middleware"
+	"github.com/ravenocx/cat-socialx/internal/models"
 )
 
 func (i *V1Routes) CatRoutes() {
 	route := i.Fiber.Group("/v1/cat")
 
-	catController := controllers.New(&controllers.V1Repository{
-		Repositories: i.Repositories,
-	})
+	catController := i.Controller
 
-	route.Get("", middleware.JWTProtected(), catController.GetCats)
-	route.Post("", middleware.JWTProtected(), catController.AddNewCat)
-	route.Delete("/:id", middleware.JWTProtected(), catController.DeleteCat)
-	route.Put("/:id", middleware.JWTProtected(), catController.UpdateCat)
+	route.Get("", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeCatsRead), catController.GetCats)
+	route.Post("", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeCatsWrite), catController.AddNewCat)
+	route.Delete("/:id", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeCatsWrite), catController.DeleteCat)
+	route.Put("/:id", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeCatsWrite), catController.UpdateCat)
+	route.Get("/:id/pedigree", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeCatsRead), catController.GetCatPedigree)
+	route.Get("/:id/health", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catController.GetCatHealthRecords)
+	route.Post("/:id/health", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catController.AddCatHealthRecord)
+	route.Put("/:id/health/:recordId", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catController.UpdateCatHealthRecord)
+	route.Delete("/:id/health/:recordId", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catController.DeleteCatHealthRecord)
 }
\ No newline at end of file
@@ -1,21 +1,27 @@
 package routes
 
 import (
-	"github.com/ravenocx/cat-socialx/internal/controllers"
 	"github.com/ravenocx/cat-socialx/internal/middleware"
+	"github.com/ravenocx/cat-socialx/internal/models"
 )
 
 func (i *V1Routes) CatMatchRoutes() {
 	route := i.Fiber.Group("/v1/cat/match")
 
-	catMatchController := controllers.New(&controllers.V1Repository{
-		Repositories: i.Repositories,
-	})
+	catMatchController := i.Controller
 
-	route.Get("", middleware.JWTProtected(), catMatchController.GetCatMatchRequests)
-	route.Post("", middleware.JWTProtected(), catMatchController.CreateCatMatch)
-	route.Post("/approve", middleware.JWTProtected(), catMatchController.ApproveCatMatch)
-	route.Post("/reject", middleware.JWTProtected(), catMatchController.RejectCatMatch)
-	route.Delete("/:id", middleware.JWTProtected(), catMatchController.DeleteCatMatch)
+	route.Get("", middleware.JWTOrAPIKey(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories, models.ScopeMatchesRead), catMatchController.GetCatMatchRequests)
+	route.Post("", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.CreateCatMatch)
+	route.Post("/approve", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.ApproveCatMatch)
+	route.Post("/reject", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.RejectCatMatch)
+	route.Delete("/:id", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.DeleteCatMatch)
+	route.Post("/:id/litter", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.RegisterLitter)
+	route.Get("/:id/health", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.GetCatMatchHealthRecords)
+	route.Post("/:id/health/share", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.ShareCatMatchHealthRecords)
+	route.Delete("/:id/health/share", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.UnshareCatMatchHealthRecords)
+	route.Get("/:id/messages", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.GetCatMatchMessages)
+	route.Post("/:id/messages", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.SendCatMatchMessage)
+	route.Post("/:id/messages/read", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.MarkCatMatchMessagesRead)
+	route.Delete("/:id/messages/:messageId", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), catMatchController.DeleteCatMatchMessage)
 
 }
\ No newline at end of file
@@ -0,0 +1,11 @@
+package routes
+
+func (i *V1Routes) EmailRoutes() {
+	route := i.Fiber.Group("/v1/email")
+
+	emailController := i.Controller
+
+	// Mail clients use POST for one-click unsubscribe (RFC 8058).
+	route.Get("/unsubscribe", emailController.UnsubscribeEmail)
+	route.Post("/unsubscribe", emailController.UnsubscribeEmail)
+}
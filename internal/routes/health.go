@@ -0,0 +1,7 @@
+package routes
+
+func (i *V1Routes) HealthRoutes() {
+	healthController := i.Controller
+
+	i.Fiber.Get("/health", healthController.GetHealth)
+}
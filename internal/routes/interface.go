@@ -2,18 +2,25 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
-	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/controllers"
 )
 
 type V1Routes struct {
-	Fiber        *fiber.App
-	Repositories *repositories.DatabaseRepositories
+	Fiber *fiber.App
+	// Controller is built once and shared by every group of routes.
+	Controller *controllers.V1Repository
 }
 
 type iV1Routes interface {
 	CatRoutes()
 	UserRoutes()
 	CatMatchRoutes()
+	StreamRoutes()
+	NotificationRoutes()
+	EmailRoutes()
+	WellKnownRoutes()
+	AdminRoutes()
+	HealthRoutes()
 }
 
 func New(v1Routes *V1Routes) iV1Routes {
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/ravenocx/cat-socialx/internal/middleware"
+)
+
+func (i *V1Routes) NotificationRoutes() {
+	route := i.Fiber.Group("/v1/notifications")
+
+	notificationController := i.Controller
+
+	route.Get("", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), notificationController.GetNotifications)
+	route.Post("/read-all", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), notificationController.MarkAllNotificationsRead)
+	route.Get("/preferences", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), notificationController.GetNotificationPreferences)
+	route.Put("/preferences", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), notificationController.UpdateNotificationPreferences)
+	route.Post("/:id/read", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), notificationController.MarkNotificationRead)
+}
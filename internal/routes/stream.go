@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/ravenocx/cat-socialx/internal/middleware"
+)
+
+func (i *V1Routes) StreamRoutes() {
+	route := i.Fiber.Group("/v1/stream")
+
+	streamController := i.Controller
+
+	route.Get("", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), streamController.StreamEvents)
+}
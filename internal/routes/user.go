@@ -1,7 +1,6 @@
 package routes
 
 import (
-	"github.com/ravenocx/cat-socialx/internal/controllers"
 	"github.com/ravenocx/cat-socialx/internal/middleware"
 )
 
@@ -9,12 +8,39 @@ func (i *V1Routes) UserRoutes() {
 	
 	route := i.Fiber.Group("/v1")
 
-	userController := controllers.New(&controllers.V1Repository{
-		Repositories: i.Repositories,
-	})
+	userController := i.Controller
 
 	route.Post("/user/register", userController.UserSignUp)
 	route.Post("/user/login", userController.UserSignIn)
-	route.Post("/token/renew", middleware.JWTProtected(), userController.RenewTokens)
+	route.Post("/user/login/mfa", userController.CompleteMFASignIn)
+	route.Get("/user/oidc/providers", userController.GetOIDCProviders)
+	route.Get("/user/oidc/:provider/login", userController.StartOIDCSignIn)
+	route.Get("/user/oidc/:provider/callback", userController.OIDCCallback)
+	// GET serves the link from the verification email.
+	route.Get("/user/verify", userController.VerifyEmail)
+	route.Post("/user/verify", userController.VerifyEmail)
+	route.Post("/user/verify/resend", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.ResendVerificationEmail)
+	route.Post("/user/password/forgot", middleware.PasswordResetLimiter(i.Controller.Config.PasswordReset.MaxPerHour), userController.ForgotPassword)
+	route.Post("/user/password/reset", userController.ResetPassword)
+	route.Get("/user/me", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.GetProfile)
+	route.Patch("/user/me", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.UpdateProfile)
+	route.Post("/user/me/password", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.ChangePassword)
+	route.Delete("/user/me", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.DeleteAccount)
+	route.Post("/user/me/email", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.ChangeEmail)
+	route.Get("/user/me/export", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.ExportUserData)
+	route.Get("/user/me/mfa", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.GetMFAStatus)
+	route.Post("/user/me/mfa/totp", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.EnrollTOTP)
+	route.Post("/user/me/mfa/totp/verify", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.ConfirmTOTP)
+	route.Delete("/user/me/mfa/totp", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.DisableMFA)
+	route.Post("/user/me/mfa/recovery-codes", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.RegenerateRecoveryCodes)
+	route.Get("/user/api-keys", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.GetAPIKeys)
+	route.Post("/user/api-keys", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.CreateAPIKey)
+	route.Delete("/user/api-keys/:id", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.RevokeAPIKey)
+	route.Get("/user/me/identities", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.GetUserIdentities)
+	route.Post("/user/me/identities/:provider", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.LinkOIDCIdentity)
+	route.Delete("/user/me/identities/:id", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.UnlinkOIDCIdentity)
+	route.Get("/user/sessions", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.GetSessions)
+	route.Delete("/user/sessions/:id", middleware.JWTProtected(i.Controller.Config.JWT, i.Controller.Keys, i.Controller.Repositories), userController.RevokeSession)
+	route.Post("/token/renew", userController.RenewTokens)
 
 }
\ No newline at end of file
@@ -0,0 +1,9 @@
+package routes
+
+func (i *V1Routes) WellKnownRoutes() {
+	route := i.Fiber.Group("/.well-known")
+
+	wellKnownController := i.Controller
+
+	route.Get("/jwks.json", wellKnownController.GetJWKS)
+}
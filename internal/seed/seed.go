@@ -0,0 +1,284 @@
+package seed
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/models"
+	"github.com/ravenocx/cat-socialx/internal/repositories"
+	"github.com/ravenocx/cat-socialx/internal/utils"
+)
+
+// Password of every seeded account.
+const Password = "Whiskers-Seed-Data-2024"
+
+var races = []string{
+	"Persian", "Maine Coon", "Siamese", "Ragdoll", "Bengal",
+	"Sphynx", "British Shorthair", "Abyssinian", "Scottish Fold", "Birman",
+}
+
+// The approved matches are seeded first, the API doesn't allow new requests
+// for a cat that is already matched.
+var statuses = []string{"approved", "pending", "rejected", "withdrawn"}
+
+var (
+	firstNames = []string{"Alice", "Bima", "Chandra", "Dewi", "Eko", "Fajar", "Gita", "Hana", "Indra", "Joko", "Kartika", "Lestari"}
+	lastNames  = []string{"Pratama", "Santoso", "Wijaya", "Kusuma", "Halim", "Nugroho", "Saputra", "Lim"}
+	catNames   = []string{"Milo", "Luna", "Oyen", "Kitty", "Simba", "Nala", "Mochi", "Tofu", "Bella", "Leo", "Cleo", "Pumpkin"}
+	traits     = []string{"playful", "calm", "curious", "shy", "fluffy", "talkative", "lazy", "gentle"}
+)
+
+type Options struct {
+	Users          int
+	CatsPerUser    int
+	MatchesPerKind int
+	Seed           int64
+	// Scale multiplies the users and matches, for load tests.
+	Scale int
+}
+
+type Summary struct {
+	Users   int
+	Cats    int
+	Matches map[string]int
+}
+
+// Run fills the database with the same data for the same options. Every
+// row goes through the validation of the API and the repositories, so the
+// data looks like what real users create.
+func Run(repo *repositories.DatabaseRepositories, cfg config.Password, opts Options) (Summary, error) {
+	summary := Summary{Matches: map[string]int{}}
+
+	if opts.Scale < 1 {
+		opts.Scale = 1
+	}
+	users := opts.Users * opts.Scale
+	matches := opts.MatchesPerKind * opts.Scale
+
+	if users < 2 || opts.CatsPerUser < 1 || matches < 0 {
+		return summary, fmt.Errorf("seeding needs at least 2 users, 1 cat per user and no negative match count")
+	}
+
+	if _, err := repo.GetUserByEmail(seedEmail(0)); err == nil {
+		return summary, fmt.Errorf("%s exists, the database is already seeded", seedEmail(0))
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return summary, err
+	}
+
+	if err := utils.ValidatePassword(cfg, Password); err != nil {
+		return summary, fmt.Errorf("the seed password doesn't pass the policy, %w", err)
+	}
+
+	// Every account shares the password, so it is hashed once.
+	passwordHash, err := utils.GeneratePassword(cfg, Password)
+	if err != nil {
+		return summary, err
+	}
+
+	s := &seeder{
+		repo:     repo,
+		rng:      rand.New(rand.NewSource(opts.Seed)),
+		validate: utils.NewValidator(),
+		base:     time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC),
+	}
+
+	for i := 0; i < users; i++ {
+		if err := s.createUser(i, passwordHash, opts.CatsPerUser); err != nil {
+			return summary, err
+		}
+	}
+
+	summary.Users = users
+	summary.Cats = len(s.cats)
+
+	for _, status := range statuses {
+		for i := 0; i < matches; i++ {
+			if err := s.createMatch(status); err != nil {
+				return summary, err
+			}
+			summary.Matches[status]++
+		}
+	}
+
+	return summary, nil
+}
+
+type seeder struct {
+	repo     *repositories.DatabaseRepositories
+	rng      *rand.Rand
+	validate *validator.Validate
+	base     time.Time
+
+	cats  []*models.Cat
+	pairs map[[2]uuid.UUID]bool
+}
+
+func seedEmail(i int) string {
+	return fmt.Sprintf("seed-user-%05d@example.com", i)
+}
+
+// newID draws the ID from the seeded source, the same run gives the same IDs.
+func (s *seeder) newID() uuid.UUID {
+	id, err := uuid.NewRandomFromReader(s.rng)
+	if err != nil {
+		// rand.Rand never fails to read.
+		panic(err)
+	}
+
+	return id
+}
+
+func (s *seeder) pick(values []string) string {
+	return values[s.rng.Intn(len(values))]
+}
+
+func (s *seeder) createUser(i int, passwordHash string, cats int) error {
+	createdAt := s.base.Add(time.Duration(i) * time.Hour)
+
+	user := &models.User{
+		ID:              s.newID(),
+		Email:           seedEmail(i),
+		Name:            s.pick(firstNames) + " " + s.pick(lastNames),
+		Password:        Password,
+		UserStatus:      1,
+		UserRole:        models.UserRoleUser,
+		CreatedAt:       createdAt,
+		EmailVerifiedAt: &createdAt,
+	}
+
+	if err := s.validate.Struct(user); err != nil {
+		return fmt.Errorf("seeded user %s is invalid : %v", user.Email, utils.ValidatorErrors(err))
+	}
+
+	user.Password = passwordHash
+
+	if err := s.repo.CreateUser(user); err != nil {
+		return fmt.Errorf("error creating the seeded user %s, %w", user.Email, err)
+	}
+
+	for c := 0; c < cats; c++ {
+		if err := s.createCat(user.ID, createdAt.Add(time.Duration(c)*time.Minute)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *seeder) createCat(userId uuid.UUID, createdAt time.Time) error {
+	id := s.newID()
+	sex := "male"
+	if s.rng.Intn(2) == 0 {
+		sex = "female"
+	}
+
+	// The races rotate, so every race has cats even in a small seed.
+	newCat := models.NewCat{
+		Name:        s.pick(catNames),
+		Race:        races[len(s.cats)%len(races)],
+		Sex:         sex,
+		AgeInMonth:  1 + s.rng.Intn(120),
+		Description: fmt.Sprintf("A %s %s looking for a partner.", s.pick(traits), sex),
+		ImageUrls:   []string{fmt.Sprintf("https://picsum.photos/seed/%s/400/400", id)},
+	}
+
+	if err := s.validate.Struct(newCat); err != nil {
+		return fmt.Errorf("seeded cat is invalid : %v", utils.ValidatorErrors(err))
+	}
+
+	cat := &models.Cat{
+		ID:        id,
+		UserID:    userId,
+		NewCat:    newCat,
+		CreatedAt: createdAt,
+	}
+
+	if err := s.repo.CreateCat(cat); err != nil {
+		return fmt.Errorf("error creating a seeded cat, %w", err)
+	}
+
+	s.cats = append(s.cats, cat)
+
+	return nil
+}
+
+// createMatch follows the rules of the API, the cats have opposite sexes,
+// different owners and no approved match yet. An approved match takes both
+// cats out of the pool.
+func (s *seeder) createMatch(status string) error {
+	if s.pairs == nil {
+		s.pairs = map[[2]uuid.UUID]bool{}
+	}
+
+	const attempts = 1000
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		issuer := s.cats[s.rng.Intn(len(s.cats))]
+		match := s.cats[s.rng.Intn(len(s.cats))]
+
+		pair := [2]uuid.UUID{issuer.ID, match.ID}
+		reverse := [2]uuid.UUID{match.ID, issuer.ID}
+		if issuer.HasMatched || match.HasMatched || issuer.Sex == match.Sex || issuer.UserID == match.UserID || s.pairs[pair] || s.pairs[reverse] {
+			continue
+		}
+
+		request := models.CatMatchRequest{
+			CatIssuerID: issuer.ID,
+			CatMatchID:  match.ID,
+			Message:     fmt.Sprintf("Hi! %s would love to meet %s.", issuer.Name, match.Name),
+		}
+
+		if err := s.validate.Struct(request); err != nil {
+			return fmt.Errorf("seeded match is invalid : %v", utils.ValidatorErrors(err))
+		}
+
+		catMatch := &models.CatMatch{
+			ID:          s.newID(),
+			CatIssuerID: request.CatIssuerID,
+			CatMatchID:  request.CatMatchID,
+			Message:     request.Message,
+			Status:      "pending",
+		}
+
+		if err := s.repo.CreateCatMatch(catMatch); err != nil {
+			return fmt.Errorf("error creating a seeded match, %w", err)
+		}
+
+		s.pairs[pair] = true
+
+		var err error
+		switch status {
+		case "approved":
+			err = s.approve(catMatch.ID, issuer, match)
+		case "rejected":
+			err = s.repo.UpdateCatMatch(catMatch.ID, "rejected")
+		case "withdrawn":
+			err = s.repo.DeleteCatMatchById(catMatch.ID)
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("no free pair of cats left for a %s match, seed more users or fewer matches", status)
+}
+
+func (s *seeder) approve(id uuid.UUID, issuer *models.Cat, match *models.Cat) error {
+	if err := s.repo.UpdateCatMatch(id, "approved"); err != nil {
+		return err
+	}
+
+	for _, cat := range []*models.Cat{issuer, match} {
+		if err := s.repo.UpdateCatHasMatched(cat.ID); err != nil {
+			return err
+		}
+		cat.HasMatched = true
+	}
+
+	return nil
+}
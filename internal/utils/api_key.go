@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key, so the middleware can tell a key from a
+// JWT and secret scanners can spot leaked keys.
+const APIKeyPrefix = "csx_"
+
+const apiKeyDisplayLength = 12
+
+// GenerateAPIKey returns the key to show once, its display prefix and the hash
+// to store.
+func GenerateAPIKey() (string, string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return key, key[:apiKeyDisplayLength], HashAPIKey(key), nil
+}
+
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
@@ -1,15 +1,16 @@
 package utils
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
-	"fmt"
-	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
 )
 
 type Tokens struct {
@@ -17,8 +18,15 @@ type Tokens struct {
 	Refresh string
 }
 
-func GenerateNewTokens(id string) (*Tokens, error) {
-	accessToken, err := generateNewAccessToken(id)
+// AccessClaims are the standard claims plus the session the token was issued
+// for, so the session list can tell which one is the current device.
+type AccessClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+}
+
+func GenerateNewTokens(cfg config.JWT, keys *jwtkeys.KeySet, id string, sessionId string) (*Tokens, error) {
+	accessToken, err := generateNewAccessToken(cfg, keys, id, sessionId)
 	if err != nil {
 		return nil, err
 	}
@@ -34,20 +42,25 @@ func GenerateNewTokens(id string) (*Tokens, error) {
 	}, nil
 }
 
-func generateNewAccessToken(id string) (string, error) {
-	secret := os.Getenv("JWT_SECRET_KEY")
-
-	minutesCount, _ := strconv.Atoi(os.Getenv("JWT_SECRET_KEY_EXPIRE_MINUTES_COUNT"))
-
-	claims := jwt.MapClaims{}
-
-	claims["id"] = id
-	claims["expires"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+func generateNewAccessToken(cfg config.JWT, keys *jwtkeys.KeySet, id string, sessionId string) (string, error) {
+	now := time.Now()
+
+	claims := AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Subject:   id,
+			Issuer:    cfg.Issuer,
+			Audience:  jwt.ClaimStrings{cfg.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(cfg.AccessTTL)),
+		},
+		SessionID: sessionId,
+	}
 
-	// Generate token.
-	t, err := token.SignedString([]byte(secret))
+	// Signed with the active key of the key set, the kid header tells
+	// verifiers which public key to use.
+	t, err := keys.Sign(claims)
 	if err != nil {
 		return "", err
 	}
@@ -55,25 +68,18 @@ func generateNewAccessToken(id string) (string, error) {
 	return t, nil
 }
 
+// The refresh token is random, its session row keeps the hash and the expiry.
 func generateNewRefreshToken() (string, error) {
-	hash := sha256.New()
-
-	refresh := os.Getenv("JWT_REFRESH_KEY") + time.Now().String()
-
-	_, err := hash.Write([]byte(refresh))
-	if err != nil {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	hoursCount, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_KEY_EXPIRE_HOURS_COUNT"))
-
-	expireTime := fmt.Sprint(time.Now().Add(time.Hour * time.Duration(hoursCount)).Unix())
-
-	t := hex.EncodeToString(hash.Sum(nil)) + "." + expireTime
-
-	return t, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func ParseRefreshToken(refreshToken string) (int64, error) {
-	return strconv.ParseInt(strings.Split(refreshToken, ".")[1], 0, 64)
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+
+	return hex.EncodeToString(sum[:])
 }
@@ -1,66 +1,82 @@
 package utils
 
 import (
-	"os"
+	"errors"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+	"github.com/ravenocx/cat-socialx/internal/models"
 )
 
-type TokenMetadata struct {
-	UserID  uuid.UUID
-	Expires int64
-}
+const principalLocalsKey = "principal"
+
+var ErrMissingPrincipal = errors.New("request is not authenticated")
+
+// ParseAccessToken verifies the signature and the standard claims of the token
+// with the keys of the set and returns its principal.
+func ParseAccessToken(cfg config.JWT, keys *jwtkeys.KeySet, tokenString string) (*models.Principal, error) {
+	claims := &AccessClaims{}
+
+	_, err := jwt.ParseWithClaims(
+		tokenString,
+		claims,
+		keys.Keyfunc,
+		jwt.WithValidMethods([]string{jwtkeys.AlgorithmRS256, jwtkeys.AlgorithmEdDSA}),
+		jwt.WithLeeway(cfg.ClockSkew),
+		jwt.WithIssuer(cfg.Issuer),
+		jwt.WithAudience(cfg.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
 
-func ExtractTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
-	token, err := verifyToken(c)
+	userID, err := uuid.Parse(claims.Subject)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if ok && token.Valid {
-		userID, err := uuid.Parse(claims["id"].(string))
-		if err != nil {
-			return nil, err
-		}
+	principal := &models.Principal{
+		UserID:     userID,
+		TokenID:    claims.ID,
+		ExpiresAt:  claims.ExpiresAt.Time,
+		AuthMethod: models.AuthMethodJWT,
+		SessionID:  claims.SessionID,
+	}
 
-		expires := int64(claims["expires"].(float64))
+	if claims.IssuedAt != nil {
+		principal.IssuedAt = claims.IssuedAt.Time
+	}
+
+	return principal, nil
+}
 
+func SetPrincipal(c *fiber.Ctx, principal *models.Principal) {
+	c.Locals(principalLocalsKey, principal)
+}
 
-		return &TokenMetadata{
-			UserID:  userID,
-			Expires: expires,
-		}, nil
+// CurrentPrincipal returns the caller verified by the middleware.
+func CurrentPrincipal(c *fiber.Ctx) (*models.Principal, error) {
+	principal, ok := c.Locals(principalLocalsKey).(*models.Principal)
+	if !ok || principal == nil {
+		return nil, ErrMissingPrincipal
 	}
 
-	return nil, err
+	return principal, nil
 }
 
-func extractToken(c *fiber.Ctx) string {
+func ExtractBearerToken(c *fiber.Ctx) string {
 	bearToken := c.Get("Authorization")
 
 	onlyToken := strings.Split(bearToken, " ")
-	if len(onlyToken) == 2 {
+	if len(onlyToken) == 2 && strings.EqualFold(onlyToken[0], "Bearer") {
 		return onlyToken[1]
 	}
 
 	return ""
 }
-
-func verifyToken(c *fiber.Ctx) (*jwt.Token, error) {
-	tokenString := extractToken(c)
-
-	token, err := jwt.Parse(tokenString, jwtKeyFunc)
-	if err != nil {
-		return nil, err
-	}
-
-	return token, nil
-}
-
-func jwtKeyFunc(token *jwt.Token) (interface{}, error) {
-	return []byte(os.Getenv("JWT_SECRET_KEY")), nil
-}
\ No newline at end of file
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+	"github.com/ravenocx/cat-socialx/internal/jwtkeys"
+)
+
+var testJWTConfig = config.JWT{
+	Issuer:     "cat-socialx",
+	Audience:   "cat-socialx-api",
+	AccessTTL:  time.Minute * 15,
+	RefreshTTL: time.Hour,
+	ClockSkew:  time.Second * 30,
+}
+
+func newTestKeySet(t *testing.T) *jwtkeys.KeySet {
+	t.Helper()
+
+	s, err := jwtkeys.Load("", "")
+	if err != nil {
+		t.Fatalf("jwtkeys.Load : %v", err)
+	}
+
+	return s
+}
+
+func TestGenerateAndParseAccessToken(t *testing.T) {
+	keys := newTestKeySet(t)
+	userId := uuid.New()
+	sessionId := uuid.NewString()
+
+	tokens, err := GenerateNewTokens(testJWTConfig, keys, userId.String(), sessionId)
+	if err != nil {
+		t.Fatalf("GenerateNewTokens : %v", err)
+	}
+
+	principal, err := ParseAccessToken(testJWTConfig, keys, tokens.Access)
+	if err != nil {
+		t.Fatalf("ParseAccessToken : %v", err)
+	}
+
+	if principal.UserID != userId || principal.SessionID != sessionId || principal.TokenID == "" {
+		t.Errorf("principal = %+v, want the user and session of the token", principal)
+	}
+	if ttl := principal.ExpiresAt.Sub(principal.IssuedAt); ttl != testJWTConfig.AccessTTL {
+		t.Errorf("token lives %s, want %s", ttl, testJWTConfig.AccessTTL)
+	}
+
+	if tokens.Refresh == "" || tokens.Refresh == tokens.Access {
+		t.Errorf("refresh token = %q, want a random token", tokens.Refresh)
+	}
+	if HashRefreshToken(tokens.Refresh) == tokens.Refresh || len(HashRefreshToken(tokens.Refresh)) != 64 {
+		t.Errorf("HashRefreshToken = %q, want a SHA-256 hex digest", HashRefreshToken(tokens.Refresh))
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	keys := newTestKeySet(t)
+	now := time.Now()
+
+	claims := func(edit func(c *AccessClaims)) jwt.Claims {
+		c := &AccessClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        uuid.NewString(),
+				Subject:   uuid.NewString(),
+				Issuer:    testJWTConfig.Issuer,
+				Audience:  jwt.ClaimStrings{testJWTConfig.Audience},
+				IssuedAt:  jwt.NewNumericDate(now),
+				NotBefore: jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			},
+		}
+		edit(c)
+
+		return c
+	}
+
+	// A key set of another deployment signs with a kid ours doesn't know.
+	otherKeys := newTestKeySet(t)
+	otherToken, _ := otherKeys.Sign(claims(func(c *AccessClaims) {}))
+
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"wrong audience", sign(t, keys, claims(func(c *AccessClaims) { c.Audience = jwt.ClaimStrings{"another-api"} })), jwt.ErrTokenInvalidAudience},
+		{"no audience", sign(t, keys, claims(func(c *AccessClaims) { c.Audience = nil })), jwt.ErrTokenRequiredClaimMissing},
+		{"wrong issuer", sign(t, keys, claims(func(c *AccessClaims) { c.Issuer = "someone-else" })), jwt.ErrTokenInvalidIssuer},
+		{"expired beyond the leeway", sign(t, keys, claims(func(c *AccessClaims) {
+			c.ExpiresAt = jwt.NewNumericDate(now.Add(-testJWTConfig.ClockSkew - time.Second))
+		})), jwt.ErrTokenExpired},
+		{"no expiry", sign(t, keys, claims(func(c *AccessClaims) { c.ExpiresAt = nil })), jwt.ErrTokenRequiredClaimMissing},
+		{"not valid yet beyond the leeway", sign(t, keys, claims(func(c *AccessClaims) {
+			c.NotBefore = jwt.NewNumericDate(now.Add(testJWTConfig.ClockSkew + time.Minute))
+		})), jwt.ErrTokenNotValidYet},
+		{"issued in the future beyond the leeway", sign(t, keys, claims(func(c *AccessClaims) {
+			c.IssuedAt = jwt.NewNumericDate(now.Add(testJWTConfig.ClockSkew + time.Minute))
+		})), jwt.ErrTokenUsedBeforeIssued},
+		{"subject isn't a user id", sign(t, keys, claims(func(c *AccessClaims) { c.Subject = "jane" })), nil},
+		{"unknown kid", otherToken, jwtkeys.ErrUnknownKeyID},
+		{"garbage", "not.a.token", jwt.ErrTokenMalformed},
+	}
+
+	for _, tt := range tests {
+		_, err := ParseAccessToken(testJWTConfig, keys, tt.token)
+		if err == nil {
+			t.Errorf("%s : ParseAccessToken accepted the token", tt.name)
+			continue
+		}
+
+		if tt.err != nil && !errors.Is(err, tt.err) {
+			t.Errorf("%s : ParseAccessToken error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestParseAccessTokenLeeway(t *testing.T) {
+	keys := newTestKeySet(t)
+	now := time.Now()
+
+	// Expired a bit less than the tolerated clock skew ago.
+	token := sign(t, keys, &AccessClaims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   uuid.NewString(),
+		Issuer:    testJWTConfig.Issuer,
+		Audience:  jwt.ClaimStrings{testJWTConfig.Audience},
+		IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(-testJWTConfig.ClockSkew + 5*time.Second)),
+	}})
+
+	if _, err := ParseAccessToken(testJWTConfig, keys, token); err != nil {
+		t.Errorf("ParseAccessToken within the leeway : %v", err)
+	}
+}
+
+func TestParseAccessTokenAfterKeyRotation(t *testing.T) {
+	keys := jwtkeys.NewKeySet()
+
+	old, _ := jwtkeys.GenerateEd25519()
+	keys.Add(old)
+	keys.SetActive(old.ID)
+
+	tokens, err := GenerateNewTokens(testJWTConfig, keys, uuid.NewString(), "")
+	if err != nil {
+		t.Fatalf("GenerateNewTokens : %v", err)
+	}
+
+	next, _ := jwtkeys.GenerateEd25519()
+	keys.Add(next)
+	keys.SetActive(next.ID)
+
+	if _, err := ParseAccessToken(testJWTConfig, keys, tokens.Access); err != nil {
+		t.Errorf("token of the previous key after the rotation : %v", err)
+	}
+}
+
+func sign(t *testing.T, keys *jwtkeys.KeySet, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := keys.Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign : %v", err)
+	}
+
+	return token
+}
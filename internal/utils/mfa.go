@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+var (
+	ErrInvalidMFAToken = errors.New("invalid mfa token")
+	ErrExpiredMFAToken = errors.New("mfa token already expired")
+)
+
+// MFAChallengeTTL is how long the user has to enter the code after the
+// password step.
+const MFAChallengeTTL = 5 * time.Minute
+
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+func mfaKey(cfg config.MFA) []byte {
+	key := sha256.Sum256([]byte(cfg.SecretKey))
+
+	return key[:]
+}
+
+// GenerateMFAChallengeToken proves the password step passed. It is signed with
+// the MFA secret key, not the JWT key, so it can never be used as an access
+// token.
+func GenerateMFAChallengeToken(cfg config.MFA, userId uuid.UUID, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(userId.String() + ":" + strconv.FormatInt(expiresAt.Unix(), 10)),
+	)
+
+	return payload + "." + signMFAPayload(cfg, payload)
+}
+
+func ParseMFAChallengeToken(cfg config.MFA, token string) (uuid.UUID, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(signMFAPayload(cfg, parts[0]))) {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	userIdStr, expiresStr, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	userId, err := uuid.Parse(userIdStr)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	expires, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAToken
+	}
+
+	if time.Now().Unix() > expires {
+		return uuid.Nil, ErrExpiredMFAToken
+	}
+
+	return userId, nil
+}
+
+func signMFAPayload(cfg config.MFA, payload string) string {
+	mac := hmac.New(sha256.New, mfaKey(cfg))
+	mac.Write([]byte("mfa-challenge:" + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// EncryptMFASecret seals the TOTP secret with AES-GCM before it is stored.
+func EncryptMFASecret(cfg config.MFA, secret string) (string, error) {
+	gcm, err := mfaCipher(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(secret), nil)
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptMFASecret(cfg config.MFA, encrypted string) (string, error) {
+	gcm, err := mfaCipher(cfg)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted mfa secret is too short")
+	}
+
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plain), nil
+}
+
+func mfaCipher(cfg config.MFA) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(mfaKey(cfg))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// GenerateRecoveryCodes returns the codes to show once and their hashes to
+// store. Codes look like "k7m2p-q9x4t".
+func GenerateRecoveryCodes(count int) ([]string, []string, error) {
+	codes := make([]string, 0, count)
+	hashes := make([]string, 0, count)
+
+	for n := 0; n < count; n++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		var sb strings.Builder
+		for idx, v := range b {
+			if idx == 5 {
+				sb.WriteByte('-')
+			}
+			sb.WriteByte(recoveryCodeAlphabet[int(v)%len(recoveryCodeAlphabet)])
+		}
+
+		codes = append(codes, sb.String())
+		hashes = append(hashes, HashRecoveryCode(sb.String()))
+	}
+
+	return codes, hashes, nil
+}
+
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
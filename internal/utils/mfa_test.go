@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+var testMFAConfig = config.MFA{Issuer: "Cat Social", SecretKey: "test-mfa-secret-key-of-32-characters"}
+
+func TestMFAChallengeToken(t *testing.T) {
+	userId := uuid.New()
+	token := GenerateMFAChallengeToken(testMFAConfig, userId, time.Now().Add(MFAChallengeTTL))
+
+	got, err := ParseMFAChallengeToken(testMFAConfig, token)
+	if err != nil || got != userId {
+		t.Fatalf("ParseMFAChallengeToken = %v, %v, want %v", got, err, userId)
+	}
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := GenerateMFAChallengeToken(testMFAConfig, uuid.New(), time.Now().Add(MFAChallengeTTL))
+	forgedPayload, _, _ := strings.Cut(forged, ".")
+
+	tests := []struct {
+		name  string
+		cfg   config.MFA
+		token string
+		err   error
+	}{
+		{"expired", testMFAConfig, GenerateMFAChallengeToken(testMFAConfig, userId, time.Now().Add(-time.Second)), ErrExpiredMFAToken},
+		{"other secret key", config.MFA{SecretKey: "another-mfa-secret-key-of-32-chars"}, token, ErrInvalidMFAToken},
+		{"swapped payload", testMFAConfig, forgedPayload + "." + signature, ErrInvalidMFAToken},
+		{"no signature", testMFAConfig, payload, ErrInvalidMFAToken},
+		{"empty", testMFAConfig, "", ErrInvalidMFAToken},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseMFAChallengeToken(tt.cfg, tt.token); !errors.Is(err, tt.err) {
+			t.Errorf("%s : ParseMFAChallengeToken error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMFASecretEncryption(t *testing.T) {
+	encrypted, err := EncryptMFASecret(testMFAConfig, rfc6238Secret)
+	if err != nil {
+		t.Fatalf("EncryptMFASecret : %v", err)
+	}
+
+	if strings.Contains(encrypted, rfc6238Secret) {
+		t.Fatal("the encrypted secret contains the plain secret")
+	}
+
+	again, _ := EncryptMFASecret(testMFAConfig, rfc6238Secret)
+	if again == encrypted {
+		t.Error("two encryptions are identical, the nonce isn't random")
+	}
+
+	plain, err := DecryptMFASecret(testMFAConfig, encrypted)
+	if err != nil || plain != rfc6238Secret {
+		t.Fatalf("DecryptMFASecret = %q, %v, want the secret", plain, err)
+	}
+
+	if _, err := DecryptMFASecret(config.MFA{SecretKey: "another-mfa-secret-key-of-32-chars"}, encrypted); err == nil {
+		t.Error("DecryptMFASecret succeeded with another key")
+	}
+	if _, err := DecryptMFASecret(testMFAConfig, "c2hvcnQ"); err == nil {
+		t.Error("DecryptMFASecret accepted a truncated value")
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(8)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes : %v", err)
+	}
+
+	if len(codes) != 8 || len(hashes) != 8 {
+		t.Fatalf("got %d codes and %d hashes, want 8 of each", len(codes), len(hashes))
+	}
+
+	seen := map[string]bool{}
+	for n, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q isn't formatted like k7m2p-q9x4t", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q was generated twice", code)
+		}
+		seen[code] = true
+
+		// Users may type the codes without the dash, uppercase or spaced.
+		typed := strings.ToUpper(strings.Replace(code, "-", " ", 1))
+		if HashRecoveryCode(typed) != hashes[n] {
+			t.Errorf("the hash of %q doesn't match the hash of %q", typed, code)
+		}
+	}
+}
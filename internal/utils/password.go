@@ -1,45 +1,197 @@
 package utils
 
 import (
-	"os"
-	"strconv"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
+	"github.com/ravenocx/cat-socialx/config"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func NormalizePassword(p string) []byte {
-	return []byte(p)
+const (
+	HasherArgon2id = "argon2id"
+	HasherBcrypt   = "bcrypt"
+)
+
+// bcrypt only hashes the first 72 bytes and refuses longer passwords.
+const bcryptMaxPasswordBytes = 72
+
+var (
+	ErrPasswordMismatch    = errors.New("password does not match")
+	ErrUnknownPasswordHash = errors.New("unknown password hash format")
+)
+
+// Argon2Params are encoded in every hash, so changing them only affects new
+// hashes and NeedsRehash upgrades the old ones on login.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2Params takes the configured cost, the defaults follow the OWASP
+// recommendation.
+func NewArgon2Params(cfg config.Password) Argon2Params {
+	return Argon2Params{
+		Memory:      uint32(cfg.Argon2MemoryKiB),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// CompareDummyPassword spends the time of a real comparison, so a sign in with
+// an unknown email can't be told apart by its response time.
+func CompareDummyPassword(cfg config.Password, inputPwd string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = GeneratePassword(cfg, "dummy password for timing")
+	})
+
+	_ = ComparePasswords(dummyHash, inputPwd)
 }
 
-func GeneratePassword(p string) string {
-	bytePwd := NormalizePassword(p)
+func GeneratePassword(cfg config.Password, p string) (string, error) {
+	if cfg.Hasher == HasherBcrypt {
+		return generateBcryptHash(p, cfg.BcryptCost)
+	}
 
-	saltPassword := os.Getenv("BCRYPT_SALT")
+	return generateArgon2idHash(p, NewArgon2Params(cfg))
+}
 
-	if saltPassword == "" {
-		saltPassword = "10"
+// GenerateRandomPassword returns a password for accounts set up by an
+// operator, 24 URL-safe characters that pass the password policy.
+func GenerateRandomPassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
 
-	salt, err := strconv.Atoi(saltPassword)
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func ComparePasswords(hashedPwd, inputPwd string) error {
+	switch {
+	case strings.HasPrefix(hashedPwd, "$argon2id$"):
+		return compareArgon2idHash(hashedPwd, inputPwd)
+	case strings.HasPrefix(hashedPwd, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hashedPwd), []byte(inputPwd)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return ErrPasswordMismatch
+			}
+			return err
+		}
+		return nil
+	default:
+		return ErrUnknownPasswordHash
+	}
+}
+
+// NeedsRehash tells whether the hash was made by another hasher or with other
+// parameters than the configured ones.
+func NeedsRehash(cfg config.Password, hashedPwd string) bool {
+	if cfg.Hasher == HasherBcrypt {
+		cost, err := bcrypt.Cost([]byte(hashedPwd))
+		return err != nil || cost != cfg.BcryptCost
+	}
+
+	params, _, _, err := decodeArgon2idHash(hashedPwd)
 	if err != nil {
-		return err.Error()
+		return true
 	}
 
-	hash, err := bcrypt.GenerateFromPassword(bytePwd, salt)
+	current := NewArgon2Params(cfg)
+
+	return params.Memory != current.Memory ||
+		params.Iterations != current.Iterations ||
+		params.Parallelism != current.Parallelism ||
+		params.KeyLength != current.KeyLength
+}
+
+func generateBcryptHash(p string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(p), cost)
 	if err != nil {
-		return err.Error()
+		return "", err
 	}
 
-	return string(hash)
+	return string(hash), nil
 }
 
-func ComparePasswords(hashedPwd, inputPwd string) error {
-	byteHash := NormalizePassword(hashedPwd)
-	byteInput := NormalizePassword(inputPwd)
+func generateArgon2idHash(p string, params Argon2Params) (string, error) {
+	salt := make([]byte, params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
 
-	if err := bcrypt.CompareHashAndPassword(byteHash, byteInput); err != nil {
+	key := argon2.IDKey([]byte(p), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func compareArgon2idHash(hashedPwd, inputPwd string) error {
+	params, salt, key, err := decodeArgon2idHash(hashedPwd)
+	if err != nil {
 		return err
 	}
 
+	inputKey := argon2.IDKey([]byte(inputPwd), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	if subtle.ConstantTimeCompare(key, inputKey) != 1 {
+		return ErrPasswordMismatch
+	}
+
 	return nil
 }
+
+// decodeArgon2idHash reads the PHC string format:
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>
+func decodeArgon2idHash(hashedPwd string) (Argon2Params, []byte, []byte, error) {
+	params := Argon2Params{}
+
+	parts := strings.Split(hashedPwd, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, ErrUnknownPasswordHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
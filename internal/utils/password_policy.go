@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+//go:embed data/breached_passwords.txt
+var breachedPasswordList string
+
+var breachedPasswords = loadBreachedPasswords(breachedPasswordList)
+
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	// MaxBytes bounds the UTF-8 length, 0 when the hasher takes any length.
+	MaxBytes       int
+	MinEntropyBits float64
+}
+
+// NewPasswordPolicy takes the configured policy. The maximum is only there to
+// bound the hashing cost, long passphrases are welcome. bcrypt refuses more
+// than 72 bytes, so the limit is enforced up front when it is the hasher.
+func NewPasswordPolicy(cfg config.Password) PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:      cfg.MinLength,
+		MaxLength:      cfg.MaxLength,
+		MinEntropyBits: cfg.MinEntropyBits,
+	}
+
+	if cfg.Hasher == HasherBcrypt {
+		policy.MaxBytes = bcryptMaxPasswordBytes
+	}
+
+	return policy
+}
+
+// ValidatePassword checks the password against the configured policy. The
+// personal inputs (email, name) can't be used as the password.
+func ValidatePassword(cfg config.Password, p string, personalInputs ...string) error {
+	return NewPasswordPolicy(cfg).Validate(p, personalInputs...)
+}
+
+func (policy PasswordPolicy) Validate(p string, personalInputs ...string) error {
+	length := utf8.RuneCountInString(p)
+
+	if length < policy.MinLength {
+		return fmt.Errorf("password needs at least %d characters", policy.MinLength)
+	}
+
+	if length > policy.MaxLength {
+		return fmt.Errorf("password can't be longer than %d characters", policy.MaxLength)
+	}
+
+	if policy.MaxBytes > 0 && len(p) > policy.MaxBytes {
+		return fmt.Errorf("password can't be longer than %d bytes", policy.MaxBytes)
+	}
+
+	normalized := strings.ToLower(p)
+
+	if _, found := breachedPasswords[normalized]; found {
+		return fmt.Errorf("password is too common, it appears in known data breaches")
+	}
+
+	for _, input := range personalInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input == "" {
+			continue
+		}
+
+		local, _, _ := strings.Cut(input, "@")
+		if normalized == input || normalized == local {
+			return fmt.Errorf("password can't be your email or name")
+		}
+	}
+
+	if PasswordEntropy(p) < policy.MinEntropyBits {
+		return fmt.Errorf("password is too easy to guess, use a longer password or mix different kinds of characters")
+	}
+
+	return nil
+}
+
+// PasswordEntropy estimates the entropy in bits from the character classes
+// used and the length. Repeated characters count less, so "aaaaaaaaaaaa"
+// isn't as strong as its length suggests.
+func PasswordEntropy(p string) float64 {
+	var lower, upper, digit, symbol, other bool
+	distinct := map[rune]struct{}{}
+	length := 0
+
+	for _, r := range p {
+		length++
+		distinct[r] = struct{}{}
+
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < unicode.MaxASCII:
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	if lower {
+		pool += 26
+	}
+	if upper {
+		pool += 26
+	}
+	if digit {
+		pool += 10
+	}
+	if symbol {
+		pool += 33
+	}
+	if other {
+		pool += 100
+	}
+
+	if pool == 0 {
+		return 0
+	}
+
+	effective := length
+	if limit := len(distinct) * 2; limit < effective {
+		effective = limit
+	}
+
+	return float64(effective) * math.Log2(float64(pool))
+}
+
+func loadBreachedPasswords(list string) map[string]struct{} {
+	passwords := map[string]struct{}{}
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+
+	return passwords
+}
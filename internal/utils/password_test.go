@@ -0,0 +1,186 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ravenocx/cat-socialx/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap costs keep the tests fast, the policy is the default one.
+var (
+	testArgon2Config = config.Password{
+		Hasher:            HasherArgon2id,
+		BcryptCost:        bcrypt.MinCost,
+		Argon2MemoryKiB:   1024,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+		MinLength:         8,
+		MaxLength:         128,
+		MinEntropyBits:    40,
+	}
+	testBcryptConfig = config.Password{
+		Hasher:     HasherBcrypt,
+		BcryptCost: bcrypt.MinCost,
+		MinLength:  8,
+		MaxLength:  128,
+	}
+)
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	hash, err := GeneratePassword(testArgon2Config, "tabby-on-the-roof")
+	if err != nil {
+		t.Fatalf("GeneratePassword : %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Errorf("hash = %q, want the PHC format with the configured parameters", hash)
+	}
+
+	if err := ComparePasswords(hash, "tabby-on-the-roof"); err != nil {
+		t.Errorf("ComparePasswords with the right password : %v", err)
+	}
+	if err := ComparePasswords(hash, "tabby-on-the-roof!"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("ComparePasswords with a wrong password = %v, want ErrPasswordMismatch", err)
+	}
+
+	again, _ := GeneratePassword(testArgon2Config, "tabby-on-the-roof")
+	if again == hash {
+		t.Error("two hashes of the same password are identical, the salt isn't random")
+	}
+}
+
+func TestComparePasswordsHashFormats(t *testing.T) {
+	bcryptHash, err := GeneratePassword(testBcryptConfig, "tabby-on-the-roof")
+	if err != nil {
+		t.Fatalf("GeneratePassword : %v", err)
+	}
+
+	// Hashes of the previous hasher keep working after a switch.
+	if err := ComparePasswords(bcryptHash, "tabby-on-the-roof"); err != nil {
+		t.Errorf("ComparePasswords with a bcrypt hash : %v", err)
+	}
+	if err := ComparePasswords(bcryptHash, "wrong"); !errors.Is(err, ErrPasswordMismatch) {
+		t.Errorf("ComparePasswords with a wrong password = %v, want ErrPasswordMismatch", err)
+	}
+
+	for _, hash := range []string{"", "plain text", "$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5", "$argon2id$v=19$m=1024$c2FsdA$a2V5", "$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5"} {
+		if err := ComparePasswords(hash, "tabby-on-the-roof"); !errors.Is(err, ErrUnknownPasswordHash) {
+			t.Errorf("ComparePasswords(%q) = %v, want ErrUnknownPasswordHash", hash, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2Hash, _ := GeneratePassword(testArgon2Config, "tabby-on-the-roof")
+	bcryptHash, _ := GeneratePassword(testBcryptConfig, "tabby-on-the-roof")
+
+	moreMemory := testArgon2Config
+	moreMemory.Argon2MemoryKiB = 2048
+	moreIterations := testArgon2Config
+	moreIterations.Argon2Iterations = 2
+	moreParallelism := testArgon2Config
+	moreParallelism.Argon2Parallelism = 2
+	higherCost := testBcryptConfig
+	higherCost.BcryptCost = bcrypt.MinCost + 1
+
+	tests := []struct {
+		name string
+		cfg  config.Password
+		hash string
+		want bool
+	}{
+		{"same argon2id parameters", testArgon2Config, argon2Hash, false},
+		{"more argon2id memory", moreMemory, argon2Hash, true},
+		{"more argon2id iterations", moreIterations, argon2Hash, true},
+		{"more argon2id parallelism", moreParallelism, argon2Hash, true},
+		{"bcrypt hash with argon2id configured", testArgon2Config, bcryptHash, true},
+		{"same bcrypt cost", testBcryptConfig, bcryptHash, false},
+		{"higher bcrypt cost", higherCost, bcryptHash, true},
+		{"argon2id hash with bcrypt configured", testBcryptConfig, argon2Hash, true},
+		{"unknown hash", testArgon2Config, "plain text", true},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsRehash(tt.cfg, tt.hash); got != tt.want {
+			t.Errorf("%s : NeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// The upgraded hash matches the new parameters and the same password.
+	upgraded, _ := GeneratePassword(moreMemory, "tabby-on-the-roof")
+	if NeedsRehash(moreMemory, upgraded) || ComparePasswords(upgraded, "tabby-on-the-roof") != nil {
+		t.Error("the rehashed password doesn't match the new parameters")
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      config.Password
+		password string
+		personal []string
+		err      string
+	}{
+		{"strong passphrase", testArgon2Config, "tabby-on-the-roof", nil, ""},
+		{"too short", testArgon2Config, "Ab1!xyz", nil, "at least 8 characters"},
+		{"too long", testArgon2Config, strings.Repeat("tabby-", 22), nil, "longer than 128 characters"},
+		{"breached", testArgon2Config, "Baseball", nil, "too common"},
+		{"email", testArgon2Config, "Jane.Doe@example.com", []string{"jane.doe@example.com"}, "your email or name"},
+		{"local part of the email", testArgon2Config, "jane.doe.cats", []string{"jane.doe.cats@example.com"}, "your email or name"},
+		{"name", testArgon2Config, "jane doe whiskers", []string{"", "Jane Doe Whiskers"}, "your email or name"},
+		{"repeated characters", testArgon2Config, "aaaaaaaaaaaaaaaa", nil, "too easy to guess"},
+		{"single class", testArgon2Config, "catnapss", nil, "too easy to guess"},
+		{"over 72 bytes with bcrypt", testBcryptConfig, strings.Repeat("é", 40), nil, "longer than 72 bytes"},
+		{"over 72 bytes with argon2id", testArgon2Config, strings.Repeat("éa", 20) + "Zz9!", nil, ""},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePassword(tt.cfg, tt.password, tt.personal...)
+
+		if tt.err == "" {
+			if err != nil {
+				t.Errorf("%s : ValidatePassword = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s : ValidatePassword = %v, want an error about %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestPasswordEntropy(t *testing.T) {
+	tests := []struct {
+		password string
+		bits     float64
+	}{
+		{"", 0},
+		{"abcdefgh", 8 * math.Log2(26)},
+		{"Abc123!?", 8 * math.Log2(95)},
+		// Only twice the distinct characters count.
+		{"aaaaaaaaaaaa", 2 * math.Log2(26)},
+		{"ééééé", 2 * math.Log2(100)},
+	}
+
+	for _, tt := range tests {
+		if got := PasswordEntropy(tt.password); math.Abs(got-tt.bits) > 1e-9 {
+			t.Errorf("PasswordEntropy(%q) = %.2f, want %.2f", tt.password, got, tt.bits)
+		}
+	}
+}
+
+func TestGenerateRandomPasswordPassesPolicy(t *testing.T) {
+	p, err := GenerateRandomPassword()
+	if err != nil {
+		t.Fatalf("GenerateRandomPassword : %v", err)
+	}
+
+	if err := ValidatePassword(testArgon2Config, p); err != nil {
+		t.Errorf("ValidatePassword(%q) = %v", p, err)
+	}
+}
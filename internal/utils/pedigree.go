@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// Coefficient of a first cousin mating, anything above it is shown as a warning.
+const InbreedingWarningThreshold = 0.0625
+
+type pedigreeIndex map[uuid.UUID]models.CatAncestor
+
+func newPedigreeIndex(ancestors []models.CatAncestor) pedigreeIndex {
+	index := pedigreeIndex{}
+	for _, ancestor := range ancestors {
+		index[ancestor.ID] = ancestor
+	}
+
+	return index
+}
+
+func BuildPedigree(rootId uuid.UUID, ancestors []models.CatAncestor) *models.PedigreeNode {
+	return newPedigreeIndex(ancestors).node(&rootId, map[uuid.UUID]bool{})
+}
+
+func (p pedigreeIndex) node(id *uuid.UUID, path map[uuid.UUID]bool) *models.PedigreeNode {
+	if id == nil || path[*id] {
+		return nil
+	}
+
+	cat, ok := p[*id]
+	if !ok {
+		return nil
+	}
+
+	path[*id] = true
+	defer delete(path, *id)
+
+	return &models.PedigreeNode{
+		ID:   cat.ID,
+		Name: cat.Name,
+		Race: cat.Race,
+		Sex:  cat.Sex,
+		Sire: p.node(cat.SireID, path),
+		Dam:  p.node(cat.DamID, path),
+	}
+}
+
+// InbreedingCoefficient returns Wright's coefficient of inbreeding for a kitten
+// of the given sire and dam, which is the kinship coefficient of both parents.
+// Ancestors missing from the list are treated as unrelated founders.
+func InbreedingCoefficient(sireId uuid.UUID, damId uuid.UUID, ancestors []models.CatAncestor) float64 {
+	index := newPedigreeIndex(ancestors)
+
+	return index.kinship(&sireId, &damId, map[[2]uuid.UUID]float64{})
+}
+
+func (p pedigreeIndex) parents(id uuid.UUID) (*uuid.UUID, *uuid.UUID) {
+	cat, ok := p[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return cat.SireID, cat.DamID
+}
+
+func (p pedigreeIndex) isAncestor(ancestorId uuid.UUID, id uuid.UUID) bool {
+	visited := map[uuid.UUID]bool{}
+	queue := []uuid.UUID{id}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		sire, dam := p.parents(current)
+		for _, parent := range []*uuid.UUID{sire, dam} {
+			if parent == nil || visited[*parent] {
+				continue
+			}
+			if *parent == ancestorId {
+				return true
+			}
+			visited[*parent] = true
+			queue = append(queue, *parent)
+		}
+	}
+
+	return false
+}
+
+func (p pedigreeIndex) kinship(a *uuid.UUID, b *uuid.UUID, memo map[[2]uuid.UUID]float64) float64 {
+	if a == nil || b == nil {
+		return 0
+	}
+
+	key := [2]uuid.UUID{*a, *b}
+	if value, ok := memo[key]; ok {
+		return value
+	}
+
+	var value float64
+	if *a == *b {
+		sire, dam := p.parents(*a)
+		value = (1 + p.kinship(sire, dam, memo)) / 2
+	} else {
+		// Always expand the cat that is not an ancestor of the other one.
+		if p.isAncestor(*a, *b) {
+			a, b = b, a
+		}
+		sire, dam := p.parents(*a)
+		value = (p.kinship(sire, b, memo) + p.kinship(dam, b, memo)) / 2
+	}
+
+	memo[key] = value
+	return value
+}
@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/internal/models"
+)
+
+// family builds ancestors from "name": {"sire", "dam"}, unknown parents are empty.
+type family map[string][2]string
+
+func (f family) ancestors() (map[string]uuid.UUID, []models.CatAncestor) {
+	ids := map[string]uuid.UUID{}
+	id := func(name string) *uuid.UUID {
+		if name == "" {
+			return nil
+		}
+		if _, ok := ids[name]; !ok {
+			ids[name] = uuid.New()
+		}
+		value := ids[name]
+		return &value
+	}
+
+	ancestors := []models.CatAncestor{}
+	for name, parents := range f {
+		ancestors = append(ancestors, models.CatAncestor{
+			ID:     *id(name),
+			Name:   name,
+			SireID: id(parents[0]),
+			DamID:  id(parents[1]),
+		})
+	}
+
+	return ids, ancestors
+}
+
+func TestInbreedingCoefficient(t *testing.T) {
+	tests := []struct {
+		name   string
+		family family
+		want   float64
+	}{
+		{"unrelated parents", family{
+			"sire": {"grandsire", "granddam"},
+			"dam":  {"other grandsire", "other granddam"},
+		}, 0},
+		{"unknown parents", family{
+			"sire": {"", ""},
+			"dam":  {"", ""},
+		}, 0},
+		{"full siblings", family{
+			"sire": {"grandsire", "granddam"},
+			"dam":  {"grandsire", "granddam"},
+		}, 0.25},
+		{"half siblings", family{
+			"sire": {"grandsire", "granddam"},
+			"dam":  {"grandsire", "other granddam"},
+		}, 0.125},
+		{"parent and offspring", family{
+			"sire": {"grandsire", "granddam"},
+			"dam":  {"sire", "other granddam"},
+		}, 0.25},
+		{"first cousins", family{
+			"sire":   {"uncle", "aunt"},
+			"dam":    {"father", "mother"},
+			"uncle":  {"great grandsire", "great granddam"},
+			"father": {"great grandsire", "great granddam"},
+		}, InbreedingWarningThreshold},
+		// The common grandsire comes from a full sibling mating, F = (1/2)^3 * (1 + 1/4).
+		{"half siblings whose common parent is inbred", family{
+			"sire":            {"grandsire", "granddam"},
+			"dam":             {"grandsire", "other granddam"},
+			"grandsire":       {"great grandsire", "great granddam"},
+			"great grandsire": {"founder", "founder dam"},
+			"great granddam":  {"founder", "founder dam"},
+		}, 0.125 * 1.25},
+	}
+
+	for _, tt := range tests {
+		ids, ancestors := tt.family.ancestors()
+
+		got := InbreedingCoefficient(ids["sire"], ids["dam"], ancestors)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s : InbreedingCoefficient = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInbreedingCoefficientNeedsTheCommonAncestors(t *testing.T) {
+	ids, ancestors := family{
+		"sire": {"grandsire", "granddam"},
+		"dam":  {"grandsire", "granddam"},
+	}.ancestors()
+
+	// Without the grandparents the full siblings look unrelated.
+	parents := []models.CatAncestor{}
+	for _, ancestor := range ancestors {
+		if ancestor.ID == ids["sire"] || ancestor.ID == ids["dam"] {
+			parents = append(parents, models.CatAncestor{ID: ancestor.ID})
+		}
+	}
+
+	if got := InbreedingCoefficient(ids["sire"], ids["dam"], parents); got != 0 {
+		t.Errorf("InbreedingCoefficient without the grandparents = %v, want 0", got)
+	}
+}
+
+func TestBuildPedigree(t *testing.T) {
+	ids, ancestors := family{
+		"kitten":    {"sire", "dam"},
+		"sire":      {"grandsire", ""},
+		"dam":       {"grandsire", "missing"},
+		"grandsire": {"", ""},
+		"missing":   {"", ""},
+	}.ancestors()
+
+	// The missing dam of the dam isn't in the list.
+	within := []models.CatAncestor{}
+	for _, ancestor := range ancestors {
+		if ancestor.ID != ids["missing"] {
+			within = append(within, ancestor)
+		}
+	}
+
+	root := BuildPedigree(ids["kitten"], within)
+	if root == nil || root.Name != "kitten" {
+		t.Fatalf("root = %+v, want the kitten", root)
+	}
+	if root.Sire == nil || root.Sire.Sire == nil || root.Sire.Sire.Name != "grandsire" {
+		t.Errorf("sire side = %+v, want the grandsire", root.Sire)
+	}
+	if root.Dam == nil || root.Dam.Sire == nil || root.Dam.Dam != nil {
+		t.Errorf("dam side = %+v, want the grandsire and no dam", root.Dam)
+	}
+
+	if BuildPedigree(uuid.New(), ancestors) != nil {
+		t.Error("BuildPedigree of an unknown cat isn't nil")
+	}
+}
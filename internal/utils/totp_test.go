@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 seed of RFC 6238 appendix B, "12345678901234567890"
+// in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The appendix lists 8 digit codes, ours are their last 6 digits.
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("TOTPCode(%d) : %v", tt.unix, err)
+		}
+
+		if code != tt.code {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, code, tt.code)
+		}
+	}
+}
+
+func TestTOTPCodeAcceptsPaddedLowercaseSecret(t *testing.T) {
+	code, err := TOTPCode("gezdgnbvgy3tqojqgezdgnbvgy3tqojq====", TOTPStep(time.Unix(59, 0)))
+	if err != nil || code != "287082" {
+		t.Errorf("TOTPCode = %q, %v, want 287082", code, err)
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTPSkewWindow(t *testing.T) {
+	// The first second of a step, the previous step ended a second ago.
+	now := time.Unix(1111111110, 0)
+	current := TOTPStep(now)
+
+	tests := []struct {
+		name string
+		step int64
+		ok   bool
+	}{
+		{"current step", current, true},
+		{"previous step", current - TOTPSkew, true},
+		{"next step", current + TOTPSkew, true},
+		{"two steps behind", current - TOTPSkew - 1, false},
+		{"two steps ahead", current + TOTPSkew + 1, false},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfc6238Secret, tt.step)
+		if err != nil {
+			t.Fatalf("%s : TOTPCode : %v", tt.name, err)
+		}
+
+		step, ok := ValidateTOTP(rfc6238Secret, code, now)
+		if ok != tt.ok {
+			t.Errorf("%s : ValidateTOTP = %v, want %v", tt.name, ok, tt.ok)
+		}
+		if ok && step != tt.step {
+			t.Errorf("%s : matched step %d, want %d", tt.name, step, tt.step)
+		}
+	}
+}
+
+func TestValidateTOTPCodeFormat(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	if step, ok := ValidateTOTP(rfc6238Secret, " 287 082 ", now); !ok || step != 1 {
+		t.Errorf("code with spaces = %d, %v, want step 1", step, ok)
+	}
+
+	for _, code := range []string{"", "28708", "2870820", "94287082", "abcdef"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, code, now); ok {
+			t.Errorf("ValidateTOTP accepted %q", code)
+		}
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Cat Social", "jane@example.com", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) : %v", uri, err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/Cat Social:jane@example.com" {
+		t.Errorf("URI = %q, want otpauth://totp/Cat%%20Social:jane@example.com", uri)
+	}
+
+	query := u.Query()
+	if query.Get("secret") != rfc6238Secret || query.Get("issuer") != "Cat Social" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("query = %v", query)
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret : %v", err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("secret %q decodes to %d bytes (%v), want 20", secret, len(key), err)
+	}
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+var ErrInvalidUnsubscribeToken = errors.New("invalid unsubscribe token")
+
+// GenerateUnsubscribeToken signs the user and email type so the unsubscribe
+// link in an email works without logging in. The link never expires.
+func GenerateUnsubscribeToken(cfg config.Mail, userId uuid.UUID, emailType string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(userId.String() + ":" + emailType))
+
+	return payload + "." + signUnsubscribePayload(cfg, payload)
+}
+
+func ParseUnsubscribeToken(cfg config.Mail, token string) (uuid.UUID, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(signUnsubscribePayload(cfg, parts[0]))) {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	userIdStr, emailType, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	userId, err := uuid.Parse(userIdStr)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidUnsubscribeToken
+	}
+
+	return userId, emailType, nil
+}
+
+func signUnsubscribePayload(cfg config.Mail, payload string) string {
+	mac := hmac.New(sha256.New, []byte(cfg.UnsubscribeSecret))
+	mac.Write([]byte(payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
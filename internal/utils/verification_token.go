@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ravenocx/cat-socialx/config"
+)
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrExpiredVerificationToken = errors.New("verification token already expired")
+)
+
+// GenerateVerificationToken signs the user, the email to verify and the expiry
+// time. The email is part of the token so it stops working once the user
+// changes their address.
+func GenerateVerificationToken(cfg config.EmailVerification, userId uuid.UUID, email string, expiresAt time.Time) string {
+	payload := base64.RawURLEncoding.EncodeToString(
+		[]byte(userId.String() + ":" + strconv.FormatInt(expiresAt.Unix(), 10) + ":" + email),
+	)
+
+	return payload + "." + signVerificationPayload(cfg, payload)
+}
+
+func ParseVerificationToken(cfg config.EmailVerification, token string) (uuid.UUID, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	if !hmac.Equal([]byte(parts[1]), []byte(signVerificationPayload(cfg, parts[0]))) {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	fields := strings.SplitN(string(decoded), ":", 3)
+	if len(fields) != 3 {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	userId, err := uuid.Parse(fields[0])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	expires, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidVerificationToken
+	}
+
+	if time.Now().Unix() > expires {
+		return uuid.Nil, "", ErrExpiredVerificationToken
+	}
+
+	return userId, fields[2], nil
+}
+
+func signVerificationPayload(cfg config.EmailVerification, payload string) string {
+	mac := hmac.New(sha256.New, []byte(cfg.Secret))
+	mac.Write([]byte("verify-email:" + payload))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}